@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	scrudv1 "github.com/advdv/scrud/scrud/v1"
 	validator "github.com/go-playground/validator/v10"
@@ -18,8 +19,54 @@ type Entity struct {
 	SkipStandardActions []scrudv1.ActionKind `yaml:"skip_standard_actions"`
 	// wether the entity is scoped to an organization.
 	NotOrganizationScoped bool `yaml:"not_organization_scoped"`
+	// field (and column) that holds the organization (tenant) of organization-scoped entities. Defaults to
+	// "organization_id".
+	TenantField string `yaml:"tenant_field"`
+	// field (and column) that holds the primary key. Defaults to "id".
+	PrimaryKeyField string `yaml:"primary_key_field"`
+	// type of the primary key: "string", "uuid" or "int64". Defaults to "string".
+	PrimaryKeyType string `validate:"oneof=string uuid int64" yaml:"primary_key_type"`
+	// fields (and columns) of a composite primary key, e.g. ["organization_id", "user_id"]. Replaces the
+	// primary key field, and actions identify items with a repeated "keys" message instead of "ids".
+	CompositeKey []string `validate:"omitempty,min=2,unique,dive,required" yaml:"composite_key"`
+	// id fields (and columns) that scope the entity, from the outermost to the innermost scope, e.g.
+	// ["organization_id", "project_id"]. Overrides the organization scoping when set.
+	Scope []string `validate:"unique,dive,required" yaml:"scope"`
 	// whether the entity has it changes captured.
 	NoChangesCaptures bool `yaml:"no_changes_captured"`
+	// whether concurrent writes are detected through a version field on the entity.
+	OptimisticConcurrency bool `yaml:"optimistic_concurrency"`
+	// whether create and custom mutation actions accept an idempotency key.
+	IdempotencyKeys bool `yaml:"idempotency_keys"`
+	// whether update masks may address individual keys of map fields, e.g. "labels.foo".
+	MapKeyMaskPaths bool `yaml:"map_key_mask_paths"`
+	// whether describe and list actions accept a read mask to select the fields that are returned.
+	ReadMasks bool `yaml:"read_masks"`
+	// whether list actions can include the total number of items.
+	TotalCounts bool `yaml:"total_counts"`
+	// whether list actions can jump to the first and last page.
+	EdgeCursors bool `yaml:"edge_cursors"`
+	// text columns that are searched by the search action, the action is only expected when configured.
+	SearchColumns []string `yaml:"search_columns"`
+	// text search configuration used for searching, e.g. "english". Defaults to "simple".
+	SearchLanguage string `yaml:"search_language"`
+	// field of the items that uniquely identifies them in an external system, the upsert action is only
+	// expected when configured.
+	NaturalKey string `yaml:"natural_key"`
+	// whether archived items can be purged on request, the purge action is only expected when configured.
+	PurgeAction bool `yaml:"purge_action"`
+	// how long archived items are kept before they are purged automatically, e.g. "720h". Kept forever if zero.
+	ArchiveRetention time.Duration `validate:"gte=0" yaml:"archive_retention"`
+	// child entities that are archived and restored together with this entity.
+	Children []*Child `validate:"dive" yaml:"children"`
+}
+
+// Child configures a child entity that references its parent through a foreign key.
+type Child struct {
+	// name of the child entity.
+	Entity string `validate:"required" yaml:"entity"`
+	// name of the field (and column) of the child that holds the id of the parent.
+	ForeignKey string `validate:"required" yaml:"foreign_key"`
 }
 
 // Config configures the ssaas code generation and linting.
@@ -43,12 +90,36 @@ func Load(filename string) (cfg Config, err error) {
 		if len(ent.SortingColumnNames) < 1 {
 			ent.SortingColumnNames = []string{"created_at", "updated_at"}
 		}
+
+		if ent.SearchLanguage == "" {
+			ent.SearchLanguage = "simple"
+		}
+
+		if ent.TenantField == "" {
+			ent.TenantField = "organization_id"
+		}
+
+		if ent.PrimaryKeyField == "" {
+			ent.PrimaryKeyField = "id"
+		}
+
+		if ent.PrimaryKeyType == "" {
+			ent.PrimaryKeyType = "string"
+		}
 	}
 
 	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(cfg); err != nil {
 		return cfg, fmt.Errorf("validate: %w", err)
 	}
 
+	for name, ent := range cfg.Entities {
+		for _, child := range ent.Children {
+			if _, ok := cfg.Entities[child.Entity]; !ok {
+				return cfg, fmt.Errorf("child '%s' of entity '%s' is not configured", child.Entity, name)
+			}
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -61,10 +132,78 @@ func (cfg Config) GetEntity(entName string) (*Entity, bool) {
 	return ent, true
 }
 
-func (e *Entity) RequireOrganizatioIDInItem() bool {
-	return !e.NotOrganizationScoped
+// ParentsOf returns the foreign keys of the entity, by the name of the parent entity they reference.
+func (cfg Config) ParentsOf(entName string) map[string]string {
+	parents := map[string]string{}
+	for name, ent := range cfg.Entities {
+		for _, child := range ent.Children {
+			if child.Entity == entName {
+				parents[name] = child.ForeignKey
+			}
+		}
+	}
+
+	return parents
+}
+
+// ScopeFields returns the id fields that scope the entity, which are required on items and list inputs.
+func (e *Entity) ScopeFields() []string {
+	switch {
+	case len(e.Scope) > 0:
+		return e.Scope
+	case e.NotOrganizationScoped:
+		return nil
+	default:
+		return []string{e.TenantField}
+	}
+}
+
+// Key returns the name and type of the primary key field.
+func (e *Entity) Key() (field, typ string) {
+	return e.PrimaryKeyField, e.PrimaryKeyType
+}
+
+// CompositeKeyFields returns the fields of the composite primary key, or nil if the entity has a single key.
+func (e *Entity) CompositeKeyFields() []string {
+	return e.CompositeKey
 }
 
 func (e *Entity) CanAllowChangesToBeCaptured() bool {
 	return !e.NoChangesCaptures
 }
+
+func (e *Entity) RequireVersionFields() bool {
+	return e.OptimisticConcurrency
+}
+
+func (e *Entity) RequireIdempotencyKey() bool {
+	return e.IdempotencyKeys
+}
+
+func (e *Entity) AllowMapKeyMaskPaths() bool {
+	return e.MapKeyMaskPaths
+}
+
+func (e *Entity) RequireReadMask() bool {
+	return e.ReadMasks
+}
+
+func (e *Entity) RequireTotalCount() bool {
+	return e.TotalCounts
+}
+
+func (e *Entity) RequireEdgeCursors() bool {
+	return e.EdgeCursors
+}
+
+func (e *Entity) ExpectSearchAction() bool {
+	return len(e.SearchColumns) > 0
+}
+
+func (e *Entity) ExpectUpsertAction() bool {
+	return e.NaturalKey != ""
+}
+
+func (e *Entity) ExpectPurgeAction() bool {
+	return e.PurgeAction
+}
@@ -34,6 +34,24 @@ func assertInputOutputKind(
 	}
 }
 
+func assertMethodStreaming(notify Notifier, desc protoreflect.MethodDescriptor, actKind scrudv1.ActionKind) {
+	if actKind == scrudv1.ActionKind_ACTION_KIND_CUSTOM {
+		return // custom actions may stream in any direction
+	}
+
+	if desc.IsStreamingClient() {
+		notify.Annotatef(desc, "method must not be client streaming")
+	}
+
+	if actKind == scrudv1.ActionKind_ACTION_KIND_WATCH {
+		if !desc.IsStreamingServer() {
+			notify.Annotatef(desc, "watch action must be server streaming")
+		}
+	} else if desc.IsStreamingServer() {
+		notify.Annotatef(desc, "only watch actions can be server streaming")
+	}
+}
+
 func assertMethodServiceSide(notify Notifier, desc protoreflect.MethodDescriptor, act, exp scrudv1.ServiceSide) {
 	if exp != act {
 		notify.Annotatef(desc, "method service side: %s != %s", exp.String(), act.String())
@@ -103,17 +121,29 @@ func assertSortDesc(
 	}
 }
 
+// assertArchived checks the field that selects the live and/or archived rows. It is either the tri-state
+// "archive_filter" enum, or the "show_archived" bool that only shows the archived rows when set.
 func assertArchived(
 	notify Notifier, desc protoreflect.MessageDescriptor,
 ) {
-	field := desc.Fields().ByName("show_archived")
+	if field := desc.Fields().ByName("show_archived"); field != nil {
+		if field.Kind() != protoreflect.BoolKind {
+			notify.Annotatef(field, "'show_archived' field must be a bool field, got: %s", field.Kind())
+		}
+
+		return
+	}
+
+	field := desc.Fields().ByName("archive_filter")
 	if field == nil {
-		notify.Annotatef(desc, "method's message must have an 'show_archived' field")
+		notify.Annotatef(desc, "method's message must have an 'archive_filter' or 'show_archived' field")
 		return
 	}
 
-	if field.Kind() != protoreflect.BoolKind {
-		notify.Annotatef(field, "'show_archived' field must be a bool field, got: %s", field.Kind())
+	if field.Kind() != protoreflect.EnumKind ||
+		field.Enum().FullName() != (scrudv1.ArchiveFilter(0)).Descriptor().FullName() {
+		notify.Annotatef(field, "'archive_filter' field must be a %s enum field",
+			(scrudv1.ArchiveFilter(0)).Descriptor().FullName())
 		return
 	}
 }
@@ -166,20 +196,213 @@ func assertConsiderArchived(
 	}
 }
 
+func assertWatchInputFields(
+	notify Notifier, desc protoreflect.MessageDescriptor, scopeFields []string,
+) {
+	assertMessageScopeFields(notify, desc, scopeFields)
+
+	assertCursorField(notify, desc, "resume_token")
+}
+
+func assertWatchOutputFields(notify Notifier, desc protoreflect.MessageDescriptor) {
+	field := desc.Fields().ByName("ids")
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have an 'ids' field")
+	} else if field.Cardinality() != protoreflect.Repeated || field.Kind() != protoreflect.StringKind {
+		notify.Annotatef(field, "'ids' field must be a repeated string field")
+	}
+
+	field = desc.Fields().ByName("action")
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have an 'action' field")
+	} else if field.Kind() != protoreflect.EnumKind ||
+		field.Enum().FullName() != scrudv1.ActionKind(0).Descriptor().FullName() {
+		notify.Annotatef(field, "'action' field must be a %s field", scrudv1.ActionKind(0).Descriptor().FullName())
+	}
+
+	assertCursorField(notify, desc, "resume_token")
+}
+
+func assertIdempotencyKeyField(notify Notifier, desc protoreflect.MessageDescriptor) {
+	name := "idempotency_key"
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have an '%s' field", name)
+		return
+	}
+
+	if field.Cardinality() == protoreflect.Repeated || field.Kind() != protoreflect.StringKind {
+		notify.Annotatef(field, "'%s' field must be a singular string field, got: %s", name, field.Kind())
+		return
+	}
+
+	assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
+		if fc.GetRequired() {
+			m = append(m, "must NOT be marked as 'required'")
+		}
+
+		if fc.GetString().GetMaxLen() != maxIdempotencyKeyLen {
+			m = append(m, fmt.Sprintf("must have a max_len constraint of: %d", maxIdempotencyKeyLen))
+		}
+
+		return
+	})
+}
+
+// assertReadMaskField checks the optional field mask that selects the fields of the items that are returned.
+func assertReadMaskField(notify Notifier, desc protoreflect.MessageDescriptor) {
+	name := "read_mask"
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have a '%s' field", name)
+		return
+	}
+
+	if field.Cardinality() == protoreflect.Repeated || field.Message() == nil ||
+		field.Message().FullName() != "google.protobuf.FieldMask" {
+		notify.Annotatef(field, "'%s' field must be a singular google.protobuf.FieldMask field", name)
+		return
+	}
+
+	assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
+		if fc.GetRequired() {
+			m = append(m, "must NOT be marked as 'required'")
+		}
+
+		return
+	})
+}
+
+// assertTotalCountFields checks the fields to request, and return, the total number of items that are listed.
+func assertTotalCountFields(notify Notifier, input, output protoreflect.MessageDescriptor) {
+	for _, exp := range []struct {
+		desc protoreflect.MessageDescriptor
+		name string
+		kind protoreflect.Kind
+	}{
+		{input, "include_total", protoreflect.BoolKind},
+		{output, "total_count", protoreflect.Int64Kind},
+		{output, "total_is_estimate", protoreflect.BoolKind},
+	} {
+		field := exp.desc.Fields().ByName(protoreflect.Name(exp.name))
+		if field == nil {
+			notify.Annotatef(exp.desc, "method's message must have a '%s' field", exp.name)
+			continue
+		}
+
+		if field.Cardinality() == protoreflect.Repeated || field.Kind() != exp.kind {
+			notify.Annotatef(field, "'%s' field must be a singular %s field, got: %s", exp.name, exp.kind, field.Kind())
+		}
+	}
+}
+
 func assertListInputFields(
-	notify Notifier, desc protoreflect.MessageDescriptor, mustHaveOrganizationID bool, sortingColumnNames []string,
+	notify Notifier, desc protoreflect.MessageDescriptor, scopeFields []string, sortingColumnNames []string,
 ) {
 	assertPagination(notify, desc)
 	assertSortDesc(notify, desc)
 	assertSortBy(notify, desc, sortingColumnNames)
 	assertArchived(notify, desc)
 
-	if mustHaveOrganizationID {
-		assertMessageOrganizationIDField(notify, desc)
+	assertMessageScopeFields(notify, desc, scopeFields)
+}
+
+// assertSearchInputFields checks the search query, and the fields for paging through the results.
+func assertSearchInputFields(notify Notifier, desc protoreflect.MessageDescriptor, scopeFields []string) {
+	assertPagination(notify, desc)
+	assertArchived(notify, desc)
+
+	assertMessageScopeFields(notify, desc, scopeFields)
+
+	name := "query"
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have a '%s' field", name)
+		return
+	}
+
+	if field.Cardinality() == protoreflect.Repeated || field.Kind() != protoreflect.StringKind {
+		notify.Annotatef(field, "'%s' field must be a singular string field, got: %s", name, field.Kind())
+		return
+	}
+
+	assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
+		if !fc.GetRequired() {
+			m = append(m, "must be marked as 'required'")
+		}
+
+		if fc.GetString().GetMaxLen() != maxSearchQueryLen {
+			m = append(m, fmt.Sprintf("must have a max_len constraint of: %d", maxSearchQueryLen))
+		}
+
+		return
+	})
+}
+
+// assertUpsertItemsFields checks that the items have the natural key, and optionally a mask that selects the
+// fields that are updated when the item already exists.
+func assertUpsertItemsFields(notify Notifier, desc protoreflect.MessageDescriptor, naturalKey string) {
+	field := desc.Fields().ByName("items")
+	if field == nil || field.Message() == nil {
+		return // reported by assertMessageItemsField
+	}
+
+	item := field.Message()
+	key := item.Fields().ByName(protoreflect.Name(naturalKey))
+	if key == nil {
+		notify.Annotatef(item, "message must have the natural key field '%s'", naturalKey)
+	} else {
+		if key.Cardinality() == protoreflect.Repeated || key.Message() != nil {
+			notify.Annotatef(key, "natural key field '%s' must be a singular scalar field", naturalKey)
+		}
+
+		assertFieldValidation(notify, key, func(fc *validate.FieldRules) (m []string) {
+			if !fc.GetRequired() {
+				m = append(m, "must be marked as 'required'")
+			}
+
+			return
+		})
+	}
+
+	mask := item.Fields().ByName("mask")
+	if mask == nil {
+		return // the mask is optional
+	}
+
+	if mask.Message() == nil || mask.Message().FullName() != "google.protobuf.FieldMask" {
+		notify.Annotatef(mask, "'mask' field must be a google.protobuf.FieldMask")
+		return
+	}
+
+	assertFieldValidation(notify, mask, func(fc *validate.FieldRules) (m []string) {
+		if fc.GetRequired() {
+			m = append(m, "must NOT be marked as 'required'")
+		}
+
+		return
+	})
+}
+
+// assertUpsertCreatedField checks the field that reports, per id, whether the item was created or updated.
+func assertUpsertCreatedField(notify Notifier, desc protoreflect.MessageDescriptor) {
+	name := "created"
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have a '%s' field", name)
+		return
+	}
+
+	if field.Cardinality() != protoreflect.Repeated || field.Kind() != protoreflect.BoolKind {
+		notify.Annotatef(field, "'%s' field must be a repeated bool field, got: %s", name, field.Kind())
 	}
 }
 
-const maxCursorLen = 300
+const (
+	maxCursorLen         = 300
+	maxIdempotencyKeyLen = 255
+	maxSearchQueryLen    = 500
+)
 
 func assertCursorField(
 	notify Notifier, desc protoreflect.MessageDescriptor, fieldName string,
@@ -216,15 +439,30 @@ func assertCursorFields(
 	assertCursorField(notify, output, "previous_cursor")
 }
 
+// assertEdgeCursorFields checks the fields that allow jumping to the first and the last page.
+func assertEdgeCursorFields(
+	notify Notifier, input, output protoreflect.MessageDescriptor,
+) {
+	name := "from_end"
+	if field := input.Fields().ByName(protoreflect.Name(name)); field == nil {
+		notify.Annotatef(input, "method's message must have a '%s' field", name)
+	} else if field.Cardinality() == protoreflect.Repeated || field.Kind() != protoreflect.BoolKind {
+		notify.Annotatef(field, "'%s' field must be a singular bool field, got: %s", name, field.Kind())
+	}
+
+	assertCursorField(notify, output, "first_cursor")
+	assertCursorField(notify, output, "last_cursor")
+}
+
 func assertMessageItemsField(
 	notify Notifier, desc protoreflect.MessageDescriptor,
-	checkItemIDField bool,
+	itemKey *primaryKey,
 	checkDescribeMessageInsteaOfItem bool,
 	checkUpdatedCreatedAtFields bool,
 	expectItemsFieldRequired bool,
 	maxItems uint64,
 	mustHaveMask bool,
-	mustHaveOrganizationID bool,
+	scopeFields []string,
 	mustHaveChangeRecordIDs bool,
 ) {
 	field := desc.Fields().ByName("items")
@@ -263,15 +501,13 @@ func assertMessageItemsField(
 			field.Message().FullName())
 	}
 
-	// if enabled, check that the item message has an "id" field.
-	if checkItemIDField {
-		assertMessageIDField(notify, field.Message())
+	// if enabled, check that the item message has a primary key field.
+	if itemKey != nil {
+		assertMessageIDField(notify, field.Message(), *itemKey)
 	}
 
-	// if enabled, check that the item message has an "organization_id" field.
-	if mustHaveOrganizationID {
-		assertMessageOrganizationIDField(notify, field.Message())
-	}
+	// check that the item message has the fields that scope the entity.
+	assertMessageScopeFields(notify, field.Message(), scopeFields)
 
 	// if enabled, check that the item has a valid date field.
 	if checkUpdatedCreatedAtFields {
@@ -350,6 +586,117 @@ func assertMessageItemsChangeRecordIDsField(notify Notifier, desc protoreflect.M
 	})
 }
 
+// assertMessageItemsVersionField checks that the item of the message has a version field. It is used to detect
+// concurrent writes to the same item.
+func assertMessageItemsVersionField(notify Notifier, desc protoreflect.MessageDescriptor) {
+	field := desc.Fields().ByName("items")
+	if field == nil || field.Message() == nil {
+		return // reported by assertMessageItemsField
+	}
+
+	assertMessageVersionField(notify, field.Message())
+}
+
+func assertMessageVersionField(notify Notifier, desc protoreflect.MessageDescriptor) {
+	name := "version"
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "message must have an '%s' field", name)
+		return
+	}
+
+	if field.Cardinality() == protoreflect.Repeated || field.Kind() != protoreflect.Int64Kind {
+		notify.Annotatef(field, "'%s' field must be a singular int64 field, got: %s", name, field.Kind())
+		return
+	}
+
+	assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
+		if !fc.GetRequired() {
+			m = append(m, "must be marked as 'required'")
+		}
+
+		return
+	})
+}
+
+// assertMessageVersionsField checks that a message with ids also has the versions for those ids.
+func assertMessageVersionsField(notify Notifier, desc protoreflect.MessageDescriptor, maxItems uint64) {
+	name := "versions"
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "method's message must have an '%s' field", name)
+		return
+	}
+
+	if field.Cardinality() != protoreflect.Repeated || field.Kind() != protoreflect.Int64Kind {
+		notify.Annotatef(field, "'%s' field must be a repeated int64 field, got: %s", name, field.Kind())
+		return
+	}
+
+	assertIDsItemsFieldValidation(notify, field, true, maxItems)
+}
+
+// assertMessageItemsMaskable checks that every field of the item can be addressed by the paths of its update
+// mask. Message types may not be recursive, and maps need string keys when their keys are addressed by the mask.
+func assertMessageItemsMaskable(notify Notifier, desc protoreflect.MessageDescriptor, allowMapKeys bool) {
+	field := desc.Fields().ByName("items")
+	if field == nil || field.Message() == nil {
+		return // reported by assertMessageItemsField
+	}
+
+	assertMaskableFields(notify, field.Message(), allowMapKeys, nil)
+}
+
+func assertMaskableFields(
+	notify Notifier, desc protoreflect.MessageDescriptor, allowMapKeys bool, seen []protoreflect.FullName,
+) {
+	seen = append(seen, desc.FullName())
+	for i := range desc.Fields().Len() {
+		field := desc.Fields().Get(i)
+		switch {
+		case field.IsMap():
+			if allowMapKeys && field.MapKey().Kind() != protoreflect.StringKind {
+				notify.Annotatef(field, "map field must have string keys to be addressed by mask paths, got: %s",
+					field.MapKey().Kind())
+			}
+		case field.Message() == nil || field.IsList() ||
+			strings.HasPrefix(string(field.Message().FullName()), "google.protobuf."):
+		case slices.Contains(seen, field.Message().FullName()):
+			notify.Annotatef(field, "field has a recursive message type that cannot be addressed by mask paths: %s",
+				field.Message().FullName())
+		default:
+			assertMaskableFields(notify, field.Message(), allowMapKeys, seen)
+		}
+	}
+}
+
+// assertMessageItemsParentField checks that the items of a child entity reference the id of their parent. The
+// reference has the kind of the parent's key, composite keys are referenced by their encoded (string) id.
+func assertMessageItemsParentField(
+	notify Notifier, desc protoreflect.MessageDescriptor, parent, foreignKey string, parentKey primaryKey,
+) {
+	field := desc.Fields().ByName("items")
+	if field == nil || field.Message() == nil {
+		return // reported by assertMessageItemsField
+	}
+
+	fk := field.Message().Fields().ByName(protoreflect.Name(foreignKey))
+	if fk == nil {
+		notify.Annotatef(field.Message(), "message must have a '%s' field that references its parent '%s'",
+			foreignKey, parent)
+		return
+	}
+
+	expKind := protoreflect.StringKind
+	if parentKey.typ == "int64" && len(parentKey.composite) < 1 {
+		expKind = protoreflect.Int64Kind
+	}
+
+	if fk.Cardinality() == protoreflect.Repeated || fk.Kind() != expKind {
+		notify.Annotatef(fk, "'%s' field must be a singular %s field, got: %s", foreignKey, expKind, fk.Kind())
+	}
+}
+
 func assertMessageItemsMaskField(notify Notifier, desc protoreflect.MessageDescriptor) {
 	name := "mask"
 	field := desc.Fields().ByName(protoreflect.Name(name))
@@ -405,15 +752,38 @@ func assertMessageTimestampField(
 	}
 }
 
-func assertMessageIDField(notify Notifier, desc protoreflect.MessageDescriptor) {
-	field := desc.Fields().ByName("id")
+// primaryKey describes the field(s) that identify the items of an entity.
+type primaryKey struct {
+	field     string
+	typ       string
+	composite []string
+}
+
+// entityKey returns the primary key of the entity's items.
+func entityKey(entCfg *config.Entity) *primaryKey {
+	field, typ := entCfg.Key()
+	return &primaryKey{field: field, typ: typ, composite: entCfg.CompositeKeyFields()}
+}
+
+func assertMessageIDField(notify Notifier, desc protoreflect.MessageDescriptor, key primaryKey) {
+	if len(key.composite) > 0 {
+		assertMessageKeyPartFields(notify, desc, key.composite)
+		return
+	}
+
+	field := desc.Fields().ByName(protoreflect.Name(key.field))
 	if field == nil {
-		notify.Annotatef(desc, "message must have an 'id' field")
+		notify.Annotatef(desc, "message must have an '%s' field", key.field)
 		return
 	}
 
-	if field.Kind() != protoreflect.StringKind {
-		notify.Annotatef(field, "'id' field must be a string field, got: %s", field.Kind())
+	expKind := protoreflect.StringKind
+	if key.typ == "int64" {
+		expKind = protoreflect.Int64Kind
+	}
+
+	if field.Kind() != expKind {
+		notify.Annotatef(field, "'%s' field must be a %s field, got: %s", key.field, expKind, field.Kind())
 	}
 
 	assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
@@ -421,20 +791,107 @@ func assertMessageIDField(notify Notifier, desc protoreflect.MessageDescriptor)
 			m = append(m, "must be marked as 'required'")
 		}
 
-		assertTypIDStringRule(notify, field, fc.GetString())
+		switch key.typ {
+		case "uuid":
+			if !fc.GetString().GetUuid() {
+				m = append(m, "must have the 'string.uuid' constraint")
+			}
+		case "int64":
+		default:
+			assertTypIDStringRule(notify, field, fc.GetString())
+		}
+
 		return
 	})
 }
 
-func assertMessageOrganizationIDField(notify Notifier, desc protoreflect.MessageDescriptor) {
-	field := desc.Fields().ByName("organization_id")
+// assertMessageKeyPartFields checks that the message has a required string or int64 field for each part of a
+// composite key.
+func assertMessageKeyPartFields(notify Notifier, desc protoreflect.MessageDescriptor, parts []string) {
+	for _, name := range parts {
+		field := desc.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			notify.Annotatef(desc, "message must have an '%s' field", name)
+			continue
+		}
+
+		if field.Cardinality() == protoreflect.Repeated ||
+			(field.Kind() != protoreflect.StringKind && field.Kind() != protoreflect.Int64Kind) {
+			notify.Annotatef(field, "'%s' field must be a singular string or int64 field, got: %s", name, field.Kind())
+		}
+
+		assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
+			if !fc.GetRequired() {
+				m = append(m, "must be marked as 'required'")
+			}
+
+			return
+		})
+	}
+}
+
+// assertMessageKeysField checks the repeated "keys" field that replaces the "ids" field for entities with a
+// composite key. The key message must have exactly the fields of the key, in the order of the key.
+func assertMessageKeysField(
+	notify Notifier, desc protoreflect.MessageDescriptor, parts []string, maxItems uint64,
+) {
+	field := desc.Fields().ByName("keys")
 	if field == nil {
-		notify.Annotatef(desc, "message must have an 'organization_id' field")
+		notify.Annotatef(desc, "method's message must have a 'keys' field")
+		return
+	}
+
+	if field.Number() != 1 {
+		notify.Annotatef(field, "'keys' field must be field number 1, got: %d", field.Number())
+	}
+
+	if field.Cardinality() != protoreflect.Repeated || field.Kind() != protoreflect.MessageKind {
+		notify.Annotatef(field, "'keys' field must be a repeated message field")
+		return
+	}
+
+	assertIDsItemsFieldValidation(notify, field, true, maxItems)
+
+	key := field.Message()
+	assertMessageKeyPartFields(notify, key, parts)
+
+	names := make([]string, 0, key.Fields().Len())
+	for i := range key.Fields().Len() {
+		names = append(names, string(key.Fields().Get(i).Name()))
+	}
+
+	if !slices.Equal(names, parts) {
+		notify.Annotatef(key, "key message must have exactly the fields: %v, in that order, got: %v", parts, names)
+	}
+}
+
+// assertIDsOrKeysField checks the "ids" field, or the "keys" field for entities with a composite key.
+func assertIDsOrKeysField(
+	notify Notifier, desc protoreflect.MessageDescriptor, entCfg *config.Entity, maxItems uint64,
+) {
+	if parts := entCfg.CompositeKeyFields(); len(parts) > 0 {
+		assertMessageKeysField(notify, desc, parts, maxItems)
+		return
+	}
+
+	assertMessageIDsField(notify, desc, maxItems)
+}
+
+func assertMessageScopeFields(notify Notifier, desc protoreflect.MessageDescriptor, scopeFields []string) {
+	for _, name := range scopeFields {
+		assertMessageScopeField(notify, desc, name)
+	}
+}
+
+func assertMessageScopeField(notify Notifier, desc protoreflect.MessageDescriptor, name string) {
+	field := desc.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		notify.Annotatef(desc, "message must have an '%s' field", name)
 		return
 	}
 
 	if field.Kind() != protoreflect.StringKind {
-		notify.Annotatef(field, "'organization_id' field must be a string field, got: %s", field.Kind())
+		notify.Annotatef(field, "'%s' field must be a string field, got: %s", name, field.Kind())
 	}
 
 	assertFieldValidation(notify, field, func(fc *validate.FieldRules) (m []string) {
@@ -568,6 +1025,12 @@ var allKinds = []scrudv1.ActionKind{
 	scrudv1.ActionKind_ACTION_KIND_RESTORE,
 }
 
+// optionalKinds are not required to be declared for each entity.
+var optionalKinds = []scrudv1.ActionKind{
+	scrudv1.ActionKind_ACTION_KIND_CUSTOM,
+	scrudv1.ActionKind_ACTION_KIND_WATCH,
+}
+
 func assertMissingOrExtra(
 	notify Notifier,
 	cfg config.Config,
@@ -593,8 +1056,8 @@ func assertMissingOrExtra(
 
 		has := map[scrudv1.ActionKind]struct{}{}
 		for _, act := range ent.GetActions() {
-			if act.GetKind() == scrudv1.ActionKind_ACTION_KIND_CUSTOM {
-				continue // custom actions are not involved in this check.
+			if slices.Contains(optionalKinds, act.GetKind()) {
+				continue // optional actions are not involved in this check.
 			}
 
 			has[act.GetKind()] = struct{}{}
@@ -608,6 +1071,19 @@ func assertMissingOrExtra(
 			exp[expKind] = struct{}{}
 		}
 
+		// the search, upsert and purge actions are only expected when they are configured.
+		if entCfg.ExpectSearchAction() {
+			exp[scrudv1.ActionKind_ACTION_KIND_SEARCH] = struct{}{}
+		}
+
+		if entCfg.ExpectUpsertAction() {
+			exp[scrudv1.ActionKind_ACTION_KIND_UPSERT] = struct{}{}
+		}
+
+		if entCfg.ExpectPurgeAction() {
+			exp[scrudv1.ActionKind_ACTION_KIND_PURGE] = struct{}{}
+		}
+
 		// the expected action setup with the actual action setup.
 		expActions := goset.From(slices.Collect(maps.Keys(exp)))
 		actActions := goset.From(slices.Collect(maps.Keys(has)))
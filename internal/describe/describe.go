@@ -3,6 +3,8 @@ package describe
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/advdv/scrud/internal/config"
 	scrudv1 "github.com/advdv/scrud/scrud/v1"
@@ -157,6 +159,7 @@ func (d describer) describeMethod(
 ) error {
 	assertMethodName(d.notifier, metDesc, entName, actKind)
 	assertInputOutputKind(d.notifier, metDesc, actKind, inputKind, ouputKind)
+	assertMethodStreaming(d.notifier, metDesc, actKind)
 
 	d.registerAction(ent, metDesc, actKind)
 
@@ -164,7 +167,7 @@ func (d describer) describeMethod(
 	case scrudv1.ActionKind_ACTION_KIND_CREATE:
 		return d.describeMethodCreate(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
 	case scrudv1.ActionKind_ACTION_KIND_DESCRIBE:
-		return d.describeMethodDescribe(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
+		return d.describeMethodDescribe(entName, entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
 	case scrudv1.ActionKind_ACTION_KIND_MODIFY:
 		return d.describeMethodModify(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
 	case scrudv1.ActionKind_ACTION_KIND_REMOVE:
@@ -173,6 +176,14 @@ func (d describer) describeMethod(
 		return d.describeMethodList(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
 	case scrudv1.ActionKind_ACTION_KIND_RESTORE:
 		return d.describeMethodRestore(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
+	case scrudv1.ActionKind_ACTION_KIND_SEARCH:
+		return d.describeMethodSearch(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
+	case scrudv1.ActionKind_ACTION_KIND_UPSERT:
+		return d.describeMethodUpsert(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
+	case scrudv1.ActionKind_ACTION_KIND_PURGE:
+		return d.describeMethodPurge(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
+	case scrudv1.ActionKind_ACTION_KIND_WATCH:
+		return d.describeMethodWatch(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output())
 	case scrudv1.ActionKind_ACTION_KIND_CUSTOM:
 		return d.describeMethodCustom(entCfg, metDesc, svcSide, metDesc.Input(), metDesc.Output(), inputKind, ouputKind)
 	case scrudv1.ActionKind_ACTION_KIND_UNSPECIFIED:
@@ -187,20 +198,25 @@ func (d describer) describeMethod(
 func (d describer) describeMethodCustom(
 	entCfg *config.Entity,
 	metDesc protoreflect.MethodDescriptor,
-	_ scrudv1.ServiceSide,
+	svcSide scrudv1.ServiceSide,
 	input, output protoreflect.MessageDescriptor,
 	inputKind scrudv1.InputKind,
 	outputKind scrudv1.OutputKind,
 ) error {
+	// custom actions on the read-write side are mutations, and might be retried.
+	if svcSide == scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE && entCfg.RequireIdempotencyKey() {
+		assertIdempotencyKeyField(d.notifier, input)
+	}
+
 	switch inputKind {
 	case scrudv1.InputKind_INPUT_KIND_IDS:
-		assertMessageIDsField(d.notifier, input, 20)
+		assertIDsOrKeysField(d.notifier, input, entCfg, 20)
 	case scrudv1.InputKind_INPUT_KIND_ITEMS:
 		assertMessageItemsField(
-			d.notifier, input, true, false, false, true, 20, false, entCfg.RequireOrganizatioIDInItem(), false)
+			d.notifier, input, entityKey(entCfg), false, false, true, 20, false, entCfg.ScopeFields(), false)
 	case scrudv1.InputKind_INPUT_KIND_NO_ID_ITEMS:
 		assertMessageItemsField(
-			d.notifier, input, false, false, false, true, 20, false, entCfg.RequireOrganizatioIDInItem(), false)
+			d.notifier, input, nil, false, false, true, 20, false, entCfg.ScopeFields(), false)
 	case scrudv1.InputKind_INPUT_KIND_UNSPECIFIED:
 		fallthrough
 	default:
@@ -210,10 +226,10 @@ func (d describer) describeMethodCustom(
 
 	switch outputKind {
 	case scrudv1.OutputKind_OUTPUT_KIND_IDS:
-		assertMessageIDsField(d.notifier, output, 20)
+		assertIDsOrKeysField(d.notifier, output, entCfg, 20)
 	case scrudv1.OutputKind_OUTPUT_KIND_ITEMS:
 		assertMessageItemsField(
-			d.notifier, output, true, false, false, true, 20, false, entCfg.RequireOrganizatioIDInItem(), false)
+			d.notifier, output, entityKey(entCfg), false, false, true, 20, false, entCfg.ScopeFields(), false)
 	case scrudv1.OutputKind_OUTPUT_KIND_EMPTY:
 		assertOutputMessageIsEmpty(d.notifier, metDesc)
 	case scrudv1.OutputKind_OUTPUT_KIND_UNSPECIFIED:
@@ -235,24 +251,43 @@ func (d describer) describeMethodCreate(
 ) error {
 	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE)
 	assertMessageItemsField(
-		d.notifier, input, false, false, false, true, 20, false, entCfg.RequireOrganizatioIDInItem(), false)
-	assertMessageIDsField(d.notifier, output, 20)
+		d.notifier, input, nil, false, false, true, 20, false, entCfg.ScopeFields(), false)
+	assertIDsOrKeysField(d.notifier, output, entCfg, 20)
+	if entCfg.RequireIdempotencyKey() {
+		assertIdempotencyKeyField(d.notifier, input)
+	}
+
 	return nil
 }
 
 // Describe action.
 func (d describer) describeMethodDescribe(
+	entName string,
 	entCfg *config.Entity,
 	metDesc protoreflect.MethodDescriptor,
 	svcSide scrudv1.ServiceSide,
 	input, output protoreflect.MessageDescriptor,
 ) error {
 	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_ONLY)
-	assertMessageIDsField(d.notifier, input, 20)
+	assertIDsOrKeysField(d.notifier, input, entCfg, 20)
 	assertMessageItemsField(
-		d.notifier, output, true, false, true, true, 20, false, entCfg.RequireOrganizatioIDInItem(),
+		d.notifier, output, entityKey(entCfg), false, true, true, 20, false, entCfg.ScopeFields(),
 		entCfg.CanAllowChangesToBeCaptured())
 	assertDescribeInputFields(d.notifier, input)
+	if entCfg.RequireVersionFields() {
+		assertMessageItemsVersionField(d.notifier, output)
+	}
+
+	if entCfg.RequireReadMask() {
+		assertReadMaskField(d.notifier, input)
+	}
+
+	parents := d.config.ParentsOf(entName)
+	for _, parent := range slices.Sorted(maps.Keys(parents)) {
+		parentCfg, _ := d.config.GetEntity(parent)
+		assertMessageItemsParentField(d.notifier, output, parent, parents[parent], *entityKey(parentCfg))
+	}
+
 	return nil
 }
 
@@ -265,21 +300,30 @@ func (d describer) describeMethodModify(
 ) error {
 	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE)
 	assertMessageItemsField(
-		d.notifier, input, true, false, false, true, 20, true, entCfg.RequireOrganizatioIDInItem(), false)
+		d.notifier, input, entityKey(entCfg), false, false, true, 20, true, entCfg.ScopeFields(), false)
 	assertOutputMessageIsEmpty(d.notifier, metDesc)
+	assertMessageItemsMaskable(d.notifier, input, entCfg.AllowMapKeyMaskPaths())
+	if entCfg.RequireVersionFields() {
+		assertMessageItemsVersionField(d.notifier, input)
+	}
+
 	return nil
 }
 
 // Remove action.
 func (d describer) describeMethodRemove(
-	_ *config.Entity,
+	entCfg *config.Entity,
 	metDesc protoreflect.MethodDescriptor,
 	svcSide scrudv1.ServiceSide,
 	input, _ protoreflect.MessageDescriptor,
 ) error {
 	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE)
-	assertMessageIDsField(d.notifier, input, 20)
+	assertIDsOrKeysField(d.notifier, input, entCfg, 20)
 	assertOutputMessageIsEmpty(d.notifier, metDesc)
+	if entCfg.RequireVersionFields() {
+		assertMessageVersionsField(d.notifier, input, 20)
+	}
+
 	return nil
 }
 
@@ -292,22 +336,96 @@ func (d describer) describeMethodList(
 ) error {
 	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_ONLY)
 	assertMessageItemsField(
-		d.notifier, output, true, true, true, false, 100, false, entCfg.RequireOrganizatioIDInItem(),
+		d.notifier, output, entityKey(entCfg), true, true, false, 100, false, entCfg.ScopeFields(),
 		entCfg.CanAllowChangesToBeCaptured())
-	assertListInputFields(d.notifier, input, entCfg.RequireOrganizatioIDInItem(), entCfg.SortingColumnNames)
+	assertListInputFields(d.notifier, input, entCfg.ScopeFields(), entCfg.SortingColumnNames)
 	assertCursorFields(d.notifier, input, output)
+	if entCfg.RequireReadMask() {
+		assertReadMaskField(d.notifier, input)
+	}
+
+	if entCfg.RequireTotalCount() {
+		assertTotalCountFields(d.notifier, input, output)
+	}
+
+	if entCfg.RequireEdgeCursors() {
+		assertEdgeCursorFields(d.notifier, input, output)
+	}
+
+	return nil
+}
+
+// Search action.
+func (d describer) describeMethodSearch(
+	entCfg *config.Entity,
+	metDesc protoreflect.MethodDescriptor,
+	svcSide scrudv1.ServiceSide,
+	input, output protoreflect.MessageDescriptor,
+) error {
+	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_ONLY)
+	assertMessageItemsField(
+		d.notifier, output, entityKey(entCfg), true, true, false, 100, false, entCfg.ScopeFields(),
+		entCfg.CanAllowChangesToBeCaptured())
+	assertSearchInputFields(d.notifier, input, entCfg.ScopeFields())
+	assertCursorFields(d.notifier, input, output)
+	return nil
+}
+
+// Purge action.
+func (d describer) describeMethodPurge(
+	entCfg *config.Entity,
+	metDesc protoreflect.MethodDescriptor,
+	svcSide scrudv1.ServiceSide,
+	input, _ protoreflect.MessageDescriptor,
+) error {
+	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE)
+	assertIDsOrKeysField(d.notifier, input, entCfg, 20)
+	assertOutputMessageIsEmpty(d.notifier, metDesc)
+	return nil
+}
+
+// Upsert action.
+func (d describer) describeMethodUpsert(
+	entCfg *config.Entity,
+	metDesc protoreflect.MethodDescriptor,
+	svcSide scrudv1.ServiceSide,
+	input, output protoreflect.MessageDescriptor,
+) error {
+	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE)
+	assertMessageItemsField(
+		d.notifier, input, nil, false, false, true, 20, false, entCfg.ScopeFields(), false)
+	assertUpsertItemsFields(d.notifier, input, entCfg.NaturalKey)
+	assertIDsOrKeysField(d.notifier, output, entCfg, 20)
+	assertUpsertCreatedField(d.notifier, output)
 	return nil
 }
 
 // Restore action.
 func (d describer) describeMethodRestore(
-	_ *config.Entity,
+	entCfg *config.Entity,
 	metDesc protoreflect.MethodDescriptor,
 	svcSide scrudv1.ServiceSide,
 	input, _ protoreflect.MessageDescriptor,
 ) error {
 	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_WRITE)
-	assertMessageIDsField(d.notifier, input, 20)
+	assertIDsOrKeysField(d.notifier, input, entCfg, 20)
 	assertOutputMessageIsEmpty(d.notifier, metDesc)
+	if entCfg.RequireVersionFields() {
+		assertMessageVersionsField(d.notifier, input, 20)
+	}
+
+	return nil
+}
+
+// Watch action.
+func (d describer) describeMethodWatch(
+	entCfg *config.Entity,
+	metDesc protoreflect.MethodDescriptor,
+	svcSide scrudv1.ServiceSide,
+	input, output protoreflect.MessageDescriptor,
+) error {
+	assertMethodServiceSide(d.notifier, metDesc, svcSide, scrudv1.ServiceSide_SERVICE_SIDE_READ_ONLY)
+	assertWatchInputFields(d.notifier, input, entCfg.ScopeFields())
+	assertWatchOutputFields(d.notifier, output)
 	return nil
 }
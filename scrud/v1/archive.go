@@ -0,0 +1,13 @@
+package scrudv1
+
+// Includes returns whether rows that are (or are not) archived pass the filter.
+func (x ArchiveFilter) Includes(archived bool) bool {
+	switch x {
+	case ArchiveFilter_ARCHIVE_FILTER_ALL:
+		return true
+	case ArchiveFilter_ARCHIVE_FILTER_ARCHIVED:
+		return archived
+	default:
+		return !archived
+	}
+}
@@ -0,0 +1,121 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: scrud/v1/archive.proto
+
+package scrudv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Describes which rows are listed, with regards to them being archived.
+type ArchiveFilter int32
+
+const (
+	// same as live.
+	ArchiveFilter_ARCHIVE_FILTER_UNSPECIFIED ArchiveFilter = 0
+	// only rows that are not archived.
+	ArchiveFilter_ARCHIVE_FILTER_LIVE ArchiveFilter = 1
+	// only rows that are archived.
+	ArchiveFilter_ARCHIVE_FILTER_ARCHIVED ArchiveFilter = 2
+	// both live and archived rows.
+	ArchiveFilter_ARCHIVE_FILTER_ALL ArchiveFilter = 3
+)
+
+// Enum value maps for ArchiveFilter.
+var (
+	ArchiveFilter_name = map[int32]string{
+		0: "ARCHIVE_FILTER_UNSPECIFIED",
+		1: "ARCHIVE_FILTER_LIVE",
+		2: "ARCHIVE_FILTER_ARCHIVED",
+		3: "ARCHIVE_FILTER_ALL",
+	}
+	ArchiveFilter_value = map[string]int32{
+		"ARCHIVE_FILTER_UNSPECIFIED": 0,
+		"ARCHIVE_FILTER_LIVE":        1,
+		"ARCHIVE_FILTER_ARCHIVED":    2,
+		"ARCHIVE_FILTER_ALL":         3,
+	}
+)
+
+func (x ArchiveFilter) Enum() *ArchiveFilter {
+	p := new(ArchiveFilter)
+	*p = x
+	return p
+}
+
+func (x ArchiveFilter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ArchiveFilter) Descriptor() protoreflect.EnumDescriptor {
+	return file_scrud_v1_archive_proto_enumTypes[0].Descriptor()
+}
+
+func (ArchiveFilter) Type() protoreflect.EnumType {
+	return &file_scrud_v1_archive_proto_enumTypes[0]
+}
+
+func (x ArchiveFilter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+var File_scrud_v1_archive_proto protoreflect.FileDescriptor
+
+const file_scrud_v1_archive_proto_rawDesc = "" +
+	"\n" +
+	"\x16scrud/v1/archive.proto\x12\bscrud.v1*}\n" +
+	"\rArchiveFilter\x12\x1e\n" +
+	"\x1aARCHIVE_FILTER_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13ARCHIVE_FILTER_LIVE\x10\x01\x12\x1b\n" +
+	"\x17ARCHIVE_FILTER_ARCHIVED\x10\x02\x12\x16\n" +
+	"\x12ARCHIVE_FILTER_ALL\x10\x03B\x86\x01\n" +
+	"\fcom.scrud.v1B\fArchiveProtoP\x01Z'github.com/advdv/scrud/scrud/v1;scrudv1\xa2\x02\x03SXX\xaa\x02\bScrud.V1\xca\x02\bScrud\\V1\xe2\x02\x14Scrud\\V1\\GPBMetadata\xea\x02\tScrud::V1b\beditionsp\xe8\a"
+
+var file_scrud_v1_archive_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_scrud_v1_archive_proto_goTypes = []any{
+	(ArchiveFilter)(0), // 0: scrud.v1.ArchiveFilter
+}
+var file_scrud_v1_archive_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_scrud_v1_archive_proto_init() }
+func file_scrud_v1_archive_proto_init() {
+	if File_scrud_v1_archive_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_scrud_v1_archive_proto_rawDesc), len(file_scrud_v1_archive_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_scrud_v1_archive_proto_goTypes,
+		DependencyIndexes: file_scrud_v1_archive_proto_depIdxs,
+		EnumInfos:         file_scrud_v1_archive_proto_enumTypes,
+	}.Build()
+	File_scrud_v1_archive_proto = out.File
+	file_scrud_v1_archive_proto_goTypes = nil
+	file_scrud_v1_archive_proto_depIdxs = nil
+}
@@ -4,12 +4,53 @@ import (
 	"fmt"
 	"time"
 
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func NewCursor(primaryID string, orderValue any, backwards bool) (*Cursor, error) {
-	crs := Cursor_builder{PrimaryId: &primaryID, IsBackwards: &backwards}.Build()
+	return NewKeyCursor(primaryID, orderValue, backwards)
+}
+
+// NewKeyCursor is like NewCursor, but the primary key may also be an integer, or a slice of strings and
+// integers for composite keys.
+func NewKeyCursor(primaryKey any, orderValue any, backwards bool) (*Cursor, error) {
+	crs := Cursor_builder{IsBackwards: &backwards}.Build()
+	switch key := primaryKey.(type) {
+	case []any:
+		parts := make([]*KeyPart, 0, len(key))
+		for _, v := range key {
+			part := &KeyPart{}
+			switch v := v.(type) {
+			case string:
+				part.SetValueString(v)
+			case int64:
+				part.SetValueInt64(v)
+			case int32:
+				part.SetValueInt64(int64(v))
+			case int:
+				part.SetValueInt64(int64(v))
+			default:
+				return nil, fmt.Errorf("unsupported primary key part: %v (%T)", v, v)
+			}
+
+			parts = append(parts, part)
+		}
+
+		crs.SetPrimaryParts(parts)
+	case string:
+		crs.SetPrimaryId(key)
+	case int64:
+		crs.SetPrimaryInt64(key)
+	case int32:
+		crs.SetPrimaryInt64(int64(key))
+	case int:
+		crs.SetPrimaryInt64(int64(key))
+	default:
+		return nil, fmt.Errorf("unsupported primary key: %v (%T)", primaryKey, primaryKey)
+	}
+
 	switch val := orderValue.(type) {
 	case string:
 		crs.SetOrderString(val)
@@ -69,6 +110,34 @@ func (x *Cursor) OrderValue() any {
 	}
 }
 
+// PrimaryKey returns the primary key of the row the cursor points at, either a string or an int64. Composite
+// keys are returned as a slice of their parts.
+func (x *Cursor) PrimaryKey() any {
+	if parts := x.GetPrimaryParts(); len(parts) > 0 {
+		key := make([]any, 0, len(parts))
+		for _, part := range parts {
+			if part.HasValueInt64() {
+				key = append(key, part.GetValueInt64())
+			} else {
+				key = append(key, part.GetValueString())
+			}
+		}
+
+		return key
+	}
+
+	if x.HasPrimaryInt64() {
+		return x.GetPrimaryInt64()
+	}
+
+	return x.GetPrimaryId()
+}
+
+// NewEdgeCursor returns a cursor for the first page, or when backwards is true: for the last page.
+func NewEdgeCursor(backwards bool) *Cursor {
+	return Cursor_builder{IsEdge: proto.Bool(true), IsBackwards: &backwards}.Build()
+}
+
 func (x *Cursor) GetIsForwards() bool {
 	return !x.GetIsBackwards()
 }
@@ -24,14 +24,17 @@ const (
 
 // Describes a pagination cursor
 type Cursor struct {
-	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_PrimaryId   *string                `protobuf:"bytes,1,opt,name=primary_id,json=primaryId"`
-	xxx_hidden_IsBackwards bool                   `protobuf:"varint,2,opt,name=is_backwards,json=isBackwards"`
-	xxx_hidden_OrderValue  isCursor_OrderValue    `protobuf_oneof:"order_value"`
-	XXX_raceDetectHookData protoimpl.RaceDetectHookData
-	XXX_presence           [1]uint32
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_PrimaryId    *string                `protobuf:"bytes,1,opt,name=primary_id,json=primaryId"`
+	xxx_hidden_IsBackwards  bool                   `protobuf:"varint,2,opt,name=is_backwards,json=isBackwards"`
+	xxx_hidden_OrderValue   isCursor_OrderValue    `protobuf_oneof:"order_value"`
+	xxx_hidden_IsEdge       bool                   `protobuf:"varint,20,opt,name=is_edge,json=isEdge"`
+	xxx_hidden_PrimaryInt64 int64                  `protobuf:"varint,21,opt,name=primary_int64,json=primaryInt64"`
+	xxx_hidden_PrimaryParts *[]*KeyPart            `protobuf:"bytes,22,rep,name=primary_parts,json=primaryParts"`
+	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
+	XXX_presence            [1]uint32
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
 }
 
 func (x *Cursor) Reset() {
@@ -229,14 +232,37 @@ func (x *Cursor) GetOrderDuration() *durationpb.Duration {
 	return nil
 }
 
+func (x *Cursor) GetIsEdge() bool {
+	if x != nil {
+		return x.xxx_hidden_IsEdge
+	}
+	return false
+}
+
+func (x *Cursor) GetPrimaryInt64() int64 {
+	if x != nil {
+		return x.xxx_hidden_PrimaryInt64
+	}
+	return 0
+}
+
+func (x *Cursor) GetPrimaryParts() []*KeyPart {
+	if x != nil {
+		if x.xxx_hidden_PrimaryParts != nil {
+			return *x.xxx_hidden_PrimaryParts
+		}
+	}
+	return nil
+}
+
 func (x *Cursor) SetPrimaryId(v string) {
 	x.xxx_hidden_PrimaryId = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 6)
 }
 
 func (x *Cursor) SetIsBackwards(v bool) {
 	x.xxx_hidden_IsBackwards = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 6)
 }
 
 func (x *Cursor) SetOrderString(v string) {
@@ -318,6 +344,20 @@ func (x *Cursor) SetOrderDuration(v *durationpb.Duration) {
 	x.xxx_hidden_OrderValue = &cursor_OrderDuration{v}
 }
 
+func (x *Cursor) SetIsEdge(v bool) {
+	x.xxx_hidden_IsEdge = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 6)
+}
+
+func (x *Cursor) SetPrimaryInt64(v int64) {
+	x.xxx_hidden_PrimaryInt64 = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 6)
+}
+
+func (x *Cursor) SetPrimaryParts(v []*KeyPart) {
+	x.xxx_hidden_PrimaryParts = &v
+}
+
 func (x *Cursor) HasPrimaryId() bool {
 	if x == nil {
 		return false
@@ -475,6 +515,20 @@ func (x *Cursor) HasOrderDuration() bool {
 	return ok
 }
 
+func (x *Cursor) HasIsEdge() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *Cursor) HasPrimaryInt64() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
 func (x *Cursor) ClearPrimaryId() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_PrimaryId = nil
@@ -591,6 +645,16 @@ func (x *Cursor) ClearOrderDuration() {
 	}
 }
 
+func (x *Cursor) ClearIsEdge() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_IsEdge = false
+}
+
+func (x *Cursor) ClearPrimaryInt64() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_PrimaryInt64 = 0
+}
+
 const Cursor_OrderValue_not_set_case case_Cursor_OrderValue = 0
 const Cursor_OrderString_case case_Cursor_OrderValue = 3
 const Cursor_OrderBytes_case case_Cursor_OrderValue = 4
@@ -684,6 +748,12 @@ type Cursor_builder struct {
 	OrderTimestamp *timestamppb.Timestamp
 	OrderDuration  *durationpb.Duration
 	// -- end of xxx_hidden_OrderValue
+	// edge cursors point before the first, or after the last row. They have no primary id or order value.
+	IsEdge *bool
+	// primary key of entities with integer keys, instead of primary_id.
+	PrimaryInt64 *int64
+	// primary key of entities with composite keys, instead of primary_id.
+	PrimaryParts []*KeyPart
 }
 
 func (b0 Cursor_builder) Build() *Cursor {
@@ -691,11 +761,11 @@ func (b0 Cursor_builder) Build() *Cursor {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.PrimaryId != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 6)
 		x.xxx_hidden_PrimaryId = b.PrimaryId
 	}
 	if b.IsBackwards != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 6)
 		x.xxx_hidden_IsBackwards = *b.IsBackwards
 	}
 	if b.OrderString != nil {
@@ -749,6 +819,15 @@ func (b0 Cursor_builder) Build() *Cursor {
 	if b.OrderDuration != nil {
 		x.xxx_hidden_OrderValue = &cursor_OrderDuration{b.OrderDuration}
 	}
+	if b.IsEdge != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 6)
+		x.xxx_hidden_IsEdge = *b.IsEdge
+	}
+	if b.PrimaryInt64 != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 6)
+		x.xxx_hidden_PrimaryInt64 = *b.PrimaryInt64
+	}
+	x.xxx_hidden_PrimaryParts = &b.PrimaryParts
 	return m0
 }
 
@@ -874,11 +953,175 @@ func (*cursor_OrderTimestamp) isCursor_OrderValue() {}
 
 func (*cursor_OrderDuration) isCursor_OrderValue() {}
 
+// Describes one column of a composite primary key.
+type KeyPart struct {
+	state            protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Value isKeyPart_Value        `protobuf_oneof:"value"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *KeyPart) Reset() {
+	*x = KeyPart{}
+	mi := &file_scrud_v1_cursor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyPart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyPart) ProtoMessage() {}
+
+func (x *KeyPart) ProtoReflect() protoreflect.Message {
+	mi := &file_scrud_v1_cursor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *KeyPart) GetValueString() string {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Value.(*keyPart_ValueString); ok {
+			return x.ValueString
+		}
+	}
+	return ""
+}
+
+func (x *KeyPart) GetValueInt64() int64 {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Value.(*keyPart_ValueInt64); ok {
+			return x.ValueInt64
+		}
+	}
+	return 0
+}
+
+func (x *KeyPart) SetValueString(v string) {
+	x.xxx_hidden_Value = &keyPart_ValueString{v}
+}
+
+func (x *KeyPart) SetValueInt64(v int64) {
+	x.xxx_hidden_Value = &keyPart_ValueInt64{v}
+}
+
+func (x *KeyPart) HasValue() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Value != nil
+}
+
+func (x *KeyPart) HasValueString() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Value.(*keyPart_ValueString)
+	return ok
+}
+
+func (x *KeyPart) HasValueInt64() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Value.(*keyPart_ValueInt64)
+	return ok
+}
+
+func (x *KeyPart) ClearValue() {
+	x.xxx_hidden_Value = nil
+}
+
+func (x *KeyPart) ClearValueString() {
+	if _, ok := x.xxx_hidden_Value.(*keyPart_ValueString); ok {
+		x.xxx_hidden_Value = nil
+	}
+}
+
+func (x *KeyPart) ClearValueInt64() {
+	if _, ok := x.xxx_hidden_Value.(*keyPart_ValueInt64); ok {
+		x.xxx_hidden_Value = nil
+	}
+}
+
+const KeyPart_Value_not_set_case case_KeyPart_Value = 0
+const KeyPart_ValueString_case case_KeyPart_Value = 1
+const KeyPart_ValueInt64_case case_KeyPart_Value = 2
+
+func (x *KeyPart) WhichValue() case_KeyPart_Value {
+	if x == nil {
+		return KeyPart_Value_not_set_case
+	}
+	switch x.xxx_hidden_Value.(type) {
+	case *keyPart_ValueString:
+		return KeyPart_ValueString_case
+	case *keyPart_ValueInt64:
+		return KeyPart_ValueInt64_case
+	default:
+		return KeyPart_Value_not_set_case
+	}
+}
+
+type KeyPart_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Fields of oneof xxx_hidden_Value:
+	ValueString *string
+	ValueInt64  *int64
+	// -- end of xxx_hidden_Value
+}
+
+func (b0 KeyPart_builder) Build() *KeyPart {
+	m0 := &KeyPart{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.ValueString != nil {
+		x.xxx_hidden_Value = &keyPart_ValueString{*b.ValueString}
+	}
+	if b.ValueInt64 != nil {
+		x.xxx_hidden_Value = &keyPart_ValueInt64{*b.ValueInt64}
+	}
+	return m0
+}
+
+type case_KeyPart_Value protoreflect.FieldNumber
+
+func (x case_KeyPart_Value) String() string {
+	md := file_scrud_v1_cursor_proto_msgTypes[1].Descriptor()
+	if x == 0 {
+		return "not set"
+	}
+	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+}
+
+type isKeyPart_Value interface {
+	isKeyPart_Value()
+}
+
+type keyPart_ValueString struct {
+	ValueString string `protobuf:"bytes,1,opt,name=value_string,json=valueString,oneof"`
+}
+
+type keyPart_ValueInt64 struct {
+	ValueInt64 int64 `protobuf:"varint,2,opt,name=value_int64,json=valueInt64,oneof"`
+}
+
+func (*keyPart_ValueString) isKeyPart_Value() {}
+
+func (*keyPart_ValueInt64) isKeyPart_Value() {}
+
 var File_scrud_v1_cursor_proto protoreflect.FileDescriptor
 
 const file_scrud_v1_cursor_proto_rawDesc = "" +
 	"\n" +
-	"\x15scrud/v1/cursor.proto\x12\bscrud.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8f\x06\n" +
+	"\x15scrud/v1/cursor.proto\x12\bscrud.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x85\a\n" +
 	"\x06Cursor\x12\x1d\n" +
 	"\n" +
 	"primary_id\x18\x01 \x01(\tR\tprimaryId\x12!\n" +
@@ -905,24 +1148,34 @@ const file_scrud_v1_cursor_proto_rawDesc = "" +
 	"\n" +
 	"order_bool\x18\x11 \x01(\bH\x00R\torderBool\x12E\n" +
 	"\x0forder_timestamp\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x0eorderTimestamp\x12B\n" +
-	"\x0eorder_duration\x18\x13 \x01(\v2\x19.google.protobuf.DurationH\x00R\rorderDurationB\r\n" +
-	"\vorder_valueB\x85\x01\n" +
+	"\x0eorder_duration\x18\x13 \x01(\v2\x19.google.protobuf.DurationH\x00R\rorderDuration\x12\x17\n" +
+	"\ais_edge\x18\x14 \x01(\bR\x06isEdge\x12#\n" +
+	"\rprimary_int64\x18\x15 \x01(\x03R\fprimaryInt64\x126\n" +
+	"\rprimary_parts\x18\x16 \x03(\v2\x11.scrud.v1.KeyPartR\fprimaryPartsB\r\n" +
+	"\vorder_value\"Z\n" +
+	"\aKeyPart\x12#\n" +
+	"\fvalue_string\x18\x01 \x01(\tH\x00R\vvalueString\x12!\n" +
+	"\vvalue_int64\x18\x02 \x01(\x03H\x00R\n" +
+	"valueInt64B\a\n" +
+	"\x05valueB\x85\x01\n" +
 	"\fcom.scrud.v1B\vCursorProtoP\x01Z'github.com/advdv/scrud/scrud/v1;scrudv1\xa2\x02\x03SXX\xaa\x02\bScrud.V1\xca\x02\bScrud\\V1\xe2\x02\x14Scrud\\V1\\GPBMetadata\xea\x02\tScrud::V1b\beditionsp\xe8\a"
 
-var file_scrud_v1_cursor_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_scrud_v1_cursor_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_scrud_v1_cursor_proto_goTypes = []any{
 	(*Cursor)(nil),                // 0: scrud.v1.Cursor
-	(*timestamppb.Timestamp)(nil), // 1: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),   // 2: google.protobuf.Duration
+	(*KeyPart)(nil),               // 1: scrud.v1.KeyPart
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 3: google.protobuf.Duration
 }
 var file_scrud_v1_cursor_proto_depIdxs = []int32{
-	1, // 0: scrud.v1.Cursor.order_timestamp:type_name -> google.protobuf.Timestamp
-	2, // 1: scrud.v1.Cursor.order_duration:type_name -> google.protobuf.Duration
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 0: scrud.v1.Cursor.order_timestamp:type_name -> google.protobuf.Timestamp
+	3, // 1: scrud.v1.Cursor.order_duration:type_name -> google.protobuf.Duration
+	1, // 2: scrud.v1.Cursor.primary_parts:type_name -> scrud.v1.KeyPart
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_scrud_v1_cursor_proto_init() }
@@ -949,13 +1202,17 @@ func file_scrud_v1_cursor_proto_init() {
 		(*cursor_OrderTimestamp)(nil),
 		(*cursor_OrderDuration)(nil),
 	}
+	file_scrud_v1_cursor_proto_msgTypes[1].OneofWrappers = []any{
+		(*keyPart_ValueString)(nil),
+		(*keyPart_ValueInt64)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_scrud_v1_cursor_proto_rawDesc), len(file_scrud_v1_cursor_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
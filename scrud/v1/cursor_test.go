@@ -67,3 +67,22 @@ func TestCursorOrderValuePanic(t *testing.T) {
 		_ = cur.OrderValue() // in this state, OrderValue must panic
 	})
 }
+
+func TestCursorPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	cur, err := scrudv1.NewKeyCursor(int64(42), "foo", false)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), cur.PrimaryKey())
+
+	cur, err = scrudv1.NewKeyCursor("foo_1", "foo", false)
+	require.NoError(t, err)
+	require.Equal(t, "foo_1", cur.PrimaryKey())
+
+	cur, err = scrudv1.NewKeyCursor([]any{"org_1", int64(7)}, "foo", false)
+	require.NoError(t, err)
+	require.Equal(t, []any{"org_1", int64(7)}, cur.PrimaryKey())
+
+	_, err = scrudv1.NewKeyCursor(1.5, "foo", false)
+	require.EqualError(t, err, "unsupported primary key: 1.5 (float64)")
+}
@@ -31,6 +31,10 @@ const (
 	ActionKind_ACTION_KIND_REMOVE      ActionKind = 4
 	ActionKind_ACTION_KIND_CREATE      ActionKind = 5
 	ActionKind_ACTION_KIND_RESTORE     ActionKind = 6
+	ActionKind_ACTION_KIND_WATCH       ActionKind = 7
+	ActionKind_ACTION_KIND_SEARCH      ActionKind = 8
+	ActionKind_ACTION_KIND_UPSERT      ActionKind = 9
+	ActionKind_ACTION_KIND_PURGE       ActionKind = 10
 	ActionKind_ACTION_KIND_CUSTOM      ActionKind = 999
 )
 
@@ -44,6 +48,10 @@ var (
 		4:   "ACTION_KIND_REMOVE",
 		5:   "ACTION_KIND_CREATE",
 		6:   "ACTION_KIND_RESTORE",
+		7:   "ACTION_KIND_WATCH",
+		8:   "ACTION_KIND_SEARCH",
+		9:   "ACTION_KIND_UPSERT",
+		10:  "ACTION_KIND_PURGE",
 		999: "ACTION_KIND_CUSTOM",
 	}
 	ActionKind_value = map[string]int32{
@@ -54,6 +62,10 @@ var (
 		"ACTION_KIND_REMOVE":      4,
 		"ACTION_KIND_CREATE":      5,
 		"ACTION_KIND_RESTORE":     6,
+		"ACTION_KIND_WATCH":       7,
+		"ACTION_KIND_SEARCH":      8,
+		"ACTION_KIND_UPSERT":      9,
+		"ACTION_KIND_PURGE":       10,
 		"ACTION_KIND_CUSTOM":      999,
 	}
 )
@@ -511,7 +523,7 @@ const file_scrud_v1_options_proto_rawDesc = "" +
 	"\x05input\x18\x03 \x01(\x0e2\x13.scrud.v1.InputKindR\x05input\x12,\n" +
 	"\x06output\x18\x04 \x01(\x0e2\x14.scrud.v1.OutputKindR\x06output\";\n" +
 	"\x0eServiceOptions\x12)\n" +
-	"\x04side\x18\x01 \x01(\x0e2\x15.scrud.v1.ServiceSideR\x04side*\xd3\x01\n" +
+	"\x04side\x18\x01 \x01(\x0e2\x15.scrud.v1.ServiceSideR\x04side*\xb1\x02\n" +
 	"\n" +
 	"ActionKind\x12\x1b\n" +
 	"\x17ACTION_KIND_UNSPECIFIED\x10\x00\x12\x18\n" +
@@ -520,7 +532,12 @@ const file_scrud_v1_options_proto_rawDesc = "" +
 	"\x12ACTION_KIND_MODIFY\x10\x03\x12\x16\n" +
 	"\x12ACTION_KIND_REMOVE\x10\x04\x12\x16\n" +
 	"\x12ACTION_KIND_CREATE\x10\x05\x12\x17\n" +
-	"\x13ACTION_KIND_RESTORE\x10\x06\x12\x17\n" +
+	"\x13ACTION_KIND_RESTORE\x10\x06\x12\x15\n" +
+	"\x11ACTION_KIND_WATCH\x10\a\x12\x16\n" +
+	"\x12ACTION_KIND_SEARCH\x10\b\x12\x16\n" +
+	"\x12ACTION_KIND_UPSERT\x10\t\x12\x15\n" +
+	"\x11ACTION_KIND_PURGE\x10\n" +
+	"\x12\x17\n" +
 	"\x12ACTION_KIND_CUSTOM\x10\xe7\a*m\n" +
 	"\tInputKind\x12\x1a\n" +
 	"\x16INPUT_KIND_UNSPECIFIED\x10\x00\x12\x12\n" +
@@ -0,0 +1,175 @@
+package scrudchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Item is the described item of an entity whose changes are captured.
+type Item interface {
+	proto.Message
+	GetId() string
+	GetChangeRecordIds() []string
+}
+
+// Capturer records a change for every item that is mutated through the runtime helpers. The item is
+// described before and after the mutation so both states can be recorded. It is used as an interceptor:
+//
+//	scrudruntime.ModifyPerItem(modifyf, scrudruntime.WithInterceptor(capturer))
+type Capturer[T Item] struct {
+	// name of the entity the changes are recorded for.
+	Entity string
+	// table the changes are recorded in, defaults to DefaultTable.
+	Table string
+	// field of the items that holds their organization (tenant), defaults to "organization_id". It must match the
+	// tenant_field of the entity's configuration. Changes of items without it are recorded without organization.
+	TenantField string
+	// Describe must describe the items by their ids, it must also consider archived items.
+	Describe func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, ids []string) ([]T, error)
+}
+
+// InterceptMutation implements scrudruntime.Interceptor.
+func (c Capturer[T]) InterceptMutation(
+	ctx context.Context,
+	logs *zap.Logger,
+	tx pgx.Tx,
+	mut scrudruntime.Mutation,
+	next scrudruntime.MutationFunc,
+) ([]string, error) {
+	// the payloads of purged items are not recorded, and they can no longer be described afterwards. So no
+	// snapshots are taken, the change only records that it happened.
+	purge := mut.Action == scrudv1.ActionKind_ACTION_KIND_PURGE
+
+	before, after := map[string]T{}, map[string]T{}
+	if !purge {
+		var err error
+		if before, err = c.snapshot(ctx, logs, tx, mut.IDs); err != nil {
+			return nil, fmt.Errorf("snapshot before: %w", err)
+		}
+	}
+
+	ids, err := next(ctx)
+	if err != nil {
+		return ids, err
+	}
+
+	// purged data must also be erased from the changes that were recorded before, the last of them still
+	// provides the organization and change records of the purged items.
+	var purged map[string]Change
+	if purge {
+		if purged, err = redact(ctx, tx, c.table(), c.Entity, ids); err != nil {
+			return nil, fmt.Errorf("redact changes: %w", err)
+		}
+	} else if after, err = c.snapshot(ctx, logs, tx, ids); err != nil {
+		return nil, fmt.Errorf("snapshot after: %w", err)
+	}
+
+	sess, _ := scrudruntime.SessionFromContext(ctx)
+	notified := map[string]struct{}{}
+	for _, id := range ids {
+		chg := Change{
+			Entity:  c.Entity,
+			ItemID:  id,
+			Action:  mut.Action.String(),
+			ActorID: sess.ActorID,
+			Mask:    mut.Masks[id].GetPaths(),
+		}
+
+		if last, ok := purged[id]; ok {
+			chg.OrganizationID, chg.ChangeRecordIDs = last.OrganizationID, last.ChangeRecordIDs
+		}
+
+		// the after state is leading for describing the change, but it might not be available.
+		for _, snap := range []map[string]T{before, after} {
+			item, ok := snap[id]
+			if !ok {
+				continue
+			}
+
+			chg.ChangeRecordIDs, chg.OrganizationID = item.GetChangeRecordIds(), c.tenant(item)
+		}
+
+		if chg.Before, err = marshal(before, id); err != nil {
+			return nil, fmt.Errorf("marshal before: %w", err)
+		}
+
+		if chg.After, err = marshal(after, id); err != nil {
+			return nil, fmt.Errorf("marshal after: %w", err)
+		}
+
+		if _, err := Record(ctx, tx, c.table(), chg); err != nil {
+			return nil, fmt.Errorf("record change of '%s': %w", id, err)
+		}
+
+		notified[chg.OrganizationID] = struct{}{}
+	}
+
+	// notify any watchers, notifications are only delivered once the transaction commits.
+	for orgID := range notified {
+		if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, c.table(), orgID); err != nil {
+			return nil, fmt.Errorf("notify watchers: %w", err)
+		}
+	}
+
+	return ids, nil
+}
+
+func (c Capturer[T]) table() string {
+	if c.Table == "" {
+		return DefaultTable
+	}
+
+	return c.Table
+}
+
+// tenant returns the organization of the item, or an empty string if it has no tenant field.
+func (c Capturer[T]) tenant(item T) string {
+	field := c.TenantField
+	if field == "" {
+		field = "organization_id"
+	}
+
+	msg := item.ProtoReflect()
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd == nil {
+		return ""
+	}
+
+	return msg.Get(fd).String()
+}
+
+func (c Capturer[T]) snapshot(ctx context.Context, logs *zap.Logger, tx pgx.Tx, ids []string) (map[string]T, error) {
+	if len(ids) < 1 {
+		return map[string]T{}, nil
+	}
+
+	items, err := c.Describe(ctx, logs, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := make(map[string]T, len(items))
+	for _, item := range items {
+		snap[item.GetId()] = item
+	}
+
+	return snap, nil
+}
+
+func marshal[T Item](snap map[string]T, id string) (json.RawMessage, error) {
+	item, ok := snap[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return protojson.Marshal(item)
+}
@@ -0,0 +1,205 @@
+package scrudchange_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudchange"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestCapturePurge(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTx{results: map[string]fakeResult{
+		"UPDATE": {cols: []string{"item_id", "position", "organization_id", "change_record_ids"}, rows: [][]any{
+			{"prj_1", int64(1), "org_1", []string{"rec_1"}},
+			{"prj_1", int64(3), "org_1", []string{"rec_2"}},
+		}},
+		"INSERT": {cols: []string{"id"}, rows: [][]any{{"chg_1"}}},
+	}}
+
+	capturer := scrudchange.Capturer[testItem]{
+		Entity: "project",
+		Describe: func(context.Context, *zap.Logger, pgx.Tx, []string) ([]testItem, error) {
+			t.Fatal("purged items must not be described")
+			return nil, nil
+		},
+	}
+
+	mut := scrudruntime.Mutation{Action: scrudv1.ActionKind_ACTION_KIND_PURGE, IDs: []string{"prj_1"}}
+	ids, err := capturer.InterceptMutation(t.Context(), zap.NewNop(), tx, mut, func(context.Context) ([]string, error) {
+		return mut.IDs, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"prj_1"}, ids)
+
+	require.Len(t, tx.stmts, 3)
+	require.Contains(t, tx.stmts[0].sql, `UPDATE "scrud_change" SET before = NULL, after = NULL`)
+	require.Equal(t, []any{"project", []string{"prj_1"}}, tx.stmts[0].args)
+
+	// the change of the purge has no payload, but is recorded in the organization of the last change.
+	require.Contains(t, tx.stmts[1].sql, `INSERT INTO "scrud_change"`)
+	require.Equal(t, []any{
+		"org_1", "project", "prj_1", []string{"rec_2"}, "ACTION_KIND_PURGE", "", []string{}, nil, nil,
+	}, tx.stmts[1].args)
+	require.Equal(t, `SELECT pg_notify($1, $2)`, tx.stmts[2].sql)
+	require.Equal(t, []any{"scrud_change", "org_1"}, tx.stmts[2].args)
+}
+
+func TestCaptureTenantField(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTx{results: map[string]fakeResult{"INSERT": {cols: []string{"id"}, rows: [][]any{{"chg_1"}}}}}
+	capturer := scrudchange.Capturer[testItem]{
+		Entity:      "project",
+		TenantField: "workspace_id",
+		Describe: func(_ context.Context, _ *zap.Logger, _ pgx.Tx, ids []string) ([]testItem, error) {
+			return []testItem{newTestItem(ids[0], "org_1", "wsp_1")}, nil
+		},
+	}
+
+	ctx := scrudruntime.WithSession(t.Context(), scrudruntime.Session{ActorID: "usr_1"})
+	mut := scrudruntime.Mutation{Action: scrudv1.ActionKind_ACTION_KIND_MODIFY, IDs: []string{"prj_1"}}
+	_, err := capturer.InterceptMutation(ctx, zap.NewNop(), tx, mut, func(context.Context) ([]string, error) {
+		return mut.IDs, nil
+	})
+	require.NoError(t, err)
+
+	// the change is recorded, and watchers are notified, in the workspace of the item.
+	require.Len(t, tx.stmts, 2)
+	require.Equal(t, "wsp_1", tx.stmts[0].args[0])
+	require.Equal(t, "usr_1", tx.stmts[0].args[5])
+	require.JSONEq(t, `{"id":"prj_1","organization_id":"org_1","workspace_id":"wsp_1"}`,
+		string(tx.stmts[0].args[8].([]byte))) //nolint:forcetypeassert
+	require.Equal(t, []any{"scrud_change", "wsp_1"}, tx.stmts[1].args)
+}
+
+// testItem is a captured project, with the organization and workspace it belongs to.
+type testItem struct{ *dynamicpb.Message }
+
+func newTestItem(id, orgID, workspaceID string) testItem {
+	item := testItem{dynamicpb.NewMessage(testItemDesc)}
+	for name, val := range map[string]string{"id": id, "organization_id": orgID, "workspace_id": workspaceID} {
+		item.Set(testItemDesc.Fields().ByName(protoreflect.Name(name)), protoreflect.ValueOfString(val))
+	}
+
+	return item
+}
+
+func (i testItem) GetId() string              { return i.Get(testItemDesc.Fields().ByName("id")).String() }
+func (testItem) GetChangeRecordIds() []string { return nil }
+
+var testItemDesc = func() protoreflect.MessageDescriptor {
+	var fields []*descriptorpb.FieldDescriptorProto
+	for i, name := range []string{"id", "organization_id", "workspace_id"} {
+		fields = append(fields, &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(int32(i + 1)),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		})
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("scrudchange_test.proto"),
+		Package:     proto.String("scrudchange.test"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Project"), Field: fields}},
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return file.Messages().Get(0)
+}()
+
+// fakeTx records the statements it executes, queries return the result for the first word of the statement.
+type fakeTx struct {
+	pgx.Tx
+
+	results map[string]fakeResult
+	stmts   []fakeStmt
+}
+
+type fakeStmt struct {
+	sql  string
+	args []any
+}
+
+type fakeResult struct {
+	cols []string
+	rows [][]any
+}
+
+func (tx *fakeTx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.stmts = append(tx.stmts, fakeStmt{sql, args})
+	return pgconn.NewCommandTag(""), nil
+}
+
+func (tx *fakeTx) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	tx.stmts = append(tx.stmts, fakeStmt{sql, args})
+	verb, _, _ := strings.Cut(sql, " ")
+	return &fakeRows{fakeResult: tx.results[verb], idx: -1}, nil
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	rows, _ := tx.Query(ctx, sql, args...)
+	return fakeRow{rows.(*fakeRows)}
+}
+
+type fakeRows struct {
+	pgx.Rows
+
+	fakeResult
+	idx int
+}
+
+func (r *fakeRows) Close()                        {}
+func (r *fakeRows) Err() error                    { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.NewCommandTag("") }
+func (r *fakeRows) Next() bool                    { r.idx++; return r.idx < len(r.rows) }
+
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, 0, len(r.cols))
+	for _, col := range r.cols {
+		fields = append(fields, pgconn.FieldDescription{Name: col})
+	}
+
+	return fields
+}
+
+func (r *fakeRows) Values() ([]any, error) { return r.rows[r.idx], nil }
+
+func (r *fakeRows) Scan(dest ...any) error {
+	for i, val := range r.rows[r.idx] {
+		if val != nil {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(val))
+		}
+	}
+
+	return nil
+}
+
+type fakeRow struct{ rows *fakeRows }
+
+func (r fakeRow) Scan(dest ...any) error {
+	if !r.rows.Next() {
+		return pgx.ErrNoRows
+	}
+
+	return r.rows.Scan(dest...)
+}
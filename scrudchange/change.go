@@ -0,0 +1,152 @@
+// Package scrudchange captures the changes that are made to entities through the runtime helpers.
+package scrudchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultTable is the name of the table that changes are recorded in, unless configured otherwise.
+const DefaultTable = "scrud_change"
+
+// TableDDL returns the statements that create the table for recording changes.
+func TableDDL(table string) []string {
+	tbl := pgx.Identifier{table}.Sanitize()
+
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+	position bigint GENERATED ALWAYS AS IDENTITY UNIQUE,
+	organization_id text NOT NULL DEFAULT '',
+	entity text NOT NULL,
+	item_id text NOT NULL,
+	change_record_ids uuid[] NOT NULL DEFAULT '{}',
+	action text NOT NULL,
+	actor_id text NOT NULL DEFAULT '',
+	mask text[] NOT NULL DEFAULT '{}',
+	before jsonb,
+	after jsonb,
+	created_at timestamptz NOT NULL DEFAULT clock_timestamp(),
+	xact_id xid8 NOT NULL DEFAULT pg_current_xact_id()
+)`, tbl),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING gin (change_record_ids)`,
+			pgx.Identifier{table + "_change_record_ids_idx"}.Sanitize(), tbl),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (organization_id, position)`,
+			pgx.Identifier{table + "_organization_id_position_idx"}.Sanitize(), tbl),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (entity, item_id)`,
+			pgx.Identifier{table + "_entity_item_id_idx"}.Sanitize(), tbl),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (organization_id, entity, xact_id, position)`,
+			pgx.Identifier{table + "_organization_id_entity_xact_id_idx"}.Sanitize(), tbl),
+	}
+}
+
+// Change is a single recorded change of an item.
+type Change struct {
+	ID              string          `db:"id"`
+	Position        int64           `db:"position"`
+	OrganizationID  string          `db:"organization_id"`
+	Entity          string          `db:"entity"`
+	ItemID          string          `db:"item_id"`
+	ChangeRecordIDs []string        `db:"change_record_ids"`
+	Action          string          `db:"action"`
+	ActorID         string          `db:"actor_id"`
+	Mask            []string        `db:"mask"`
+	Before          json.RawMessage `db:"before"`
+	After           json.RawMessage `db:"after"`
+	CreatedAt       time.Time       `db:"created_at"`
+	// id of the transaction that recorded the change, changes are only watched once it has committed.
+	XactID uint64 `db:"xact_id"`
+}
+
+// columns that are selected for a change.
+var columns = []any{
+	"id", "position", "organization_id", "entity", "item_id", "change_record_ids", "action", "actor_id", "mask",
+	"before", "after", "created_at", "xact_id",
+}
+
+// columnList returns the selected columns as a comma-separated list.
+func columnList() string {
+	names := make([]string, 0, len(columns))
+	for _, col := range columns {
+		names = append(names, fmt.Sprint(col))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Record inserts the change into the table and returns its id.
+func Record(ctx context.Context, tx pgx.Tx, table string, chg Change) (string, error) {
+	if chg.ChangeRecordIDs == nil {
+		chg.ChangeRecordIDs = []string{}
+	}
+
+	if chg.Mask == nil {
+		chg.Mask = []string{}
+	}
+
+	var id string
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`INSERT INTO %s `+
+		`(organization_id, entity, item_id, change_record_ids, action, actor_id, mask, before, after) `+
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`, pgx.Identifier{table}.Sanitize()),
+		chg.OrganizationID, chg.Entity, chg.ItemID, chg.ChangeRecordIDs, chg.Action, chg.ActorID, chg.Mask,
+		nullJSON(chg.Before), nullJSON(chg.After),
+	).Scan(&id); err != nil {
+		return "", fmt.Errorf("insert change: %w", err)
+	}
+
+	return id, nil
+}
+
+// Redact erases the before and after payloads of all changes that were recorded for the items of the entity.
+func Redact(ctx context.Context, tx pgx.Tx, table, entity string, itemIDs []string) error {
+	_, err := redact(ctx, tx, table, entity, itemIDs)
+	return err
+}
+
+// redact erases the payloads like Redact, and returns the last change that was recorded for each of the items.
+// The returned changes only have their item id, position, organization and change record ids set.
+func redact(ctx context.Context, tx pgx.Tx, table, entity string, itemIDs []string) (map[string]Change, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf(`UPDATE %s SET before = NULL, after = NULL `+
+		`WHERE entity = $1 AND item_id = ANY($2) RETURNING item_id, position, organization_id, change_record_ids`,
+		pgx.Identifier{table}.Sanitize()), entity, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("update changes: %w", err)
+	}
+
+	changes, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[Change])
+	if err != nil {
+		return nil, fmt.Errorf("collect changes: %w", err)
+	}
+
+	last := make(map[string]Change, len(itemIDs))
+	for _, chg := range changes {
+		if prev, ok := last[chg.ItemID]; !ok || chg.Position > prev.Position {
+			last[chg.ItemID] = chg
+		}
+	}
+
+	return last, nil
+}
+
+// RedactPurged returns a hook for scrudruntime.Retention that redacts the changes of the rows it purges. The
+// entity of the retention policy must match the entity that the changes were recorded for.
+func RedactPurged(table string) func(context.Context, pgx.Tx, scrudruntime.RetentionPolicy, []string) error {
+	return func(ctx context.Context, tx pgx.Tx, policy scrudruntime.RetentionPolicy, ids []string) error {
+		return Redact(ctx, tx, table, policy.Entity, ids)
+	}
+}
+
+// nullJSON turns an empty raw message into a sql NULL instead of invalid json.
+func nullJSON(msg json.RawMessage) any {
+	if len(msg) < 1 {
+		return nil
+	}
+
+	return []byte(msg)
+}
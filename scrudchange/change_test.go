@@ -0,0 +1,90 @@
+package scrudchange_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/advdv/scrud/scrudchange"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTx{results: map[string]fakeResult{"INSERT": {cols: []string{"id"}, rows: [][]any{{"chg_1"}}}}}
+	id, err := scrudchange.Record(t.Context(), tx, "scrud_change", scrudchange.Change{
+		OrganizationID: "org_1", Entity: "project", ItemID: "prj_1", Action: "ACTION_KIND_CREATE",
+		After: json.RawMessage(`{"id":"prj_1"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "chg_1", id)
+
+	// empty arrays are recorded instead of nulls, and a missing payload as sql NULL.
+	require.Contains(t, tx.stmts[0].sql, `INSERT INTO "scrud_change"`)
+	require.Equal(t, []any{
+		"org_1", "project", "prj_1", []string{}, "ACTION_KIND_CREATE", "", []string{}, nil, []byte(`{"id":"prj_1"}`),
+	}, tx.stmts[0].args)
+
+	_, err = scrudchange.Record(t.Context(), &fakeTx{}, "scrud_change", scrudchange.Change{})
+	require.ErrorContains(t, err, "insert change")
+}
+
+func TestRedactPurged(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTx{}
+	require.NoError(t, scrudchange.Redact(t.Context(), tx, "scrud_change", "project", []string{"prj_1"}))
+
+	// the hook redacts the changes of the entity of the policy.
+	hook := scrudchange.RedactPurged("my_change")
+	require.NoError(t, hook(t.Context(), tx, scrudruntime.RetentionPolicy{Entity: "task"}, []string{"tsk_1"}))
+
+	require.Len(t, tx.stmts, 2)
+	require.Contains(t, tx.stmts[0].sql, `UPDATE "scrud_change" SET before = NULL, after = NULL WHERE entity = $1`)
+	require.Equal(t, []any{"project", []string{"prj_1"}}, tx.stmts[0].args)
+	require.Contains(t, tx.stmts[1].sql, `UPDATE "my_change"`)
+	require.Equal(t, []any{"task", []string{"tsk_1"}}, tx.stmts[1].args)
+}
+
+func TestRecordListAndRedact(t *testing.T) {
+	t.Parallel()
+
+	conn, table := setupChangeTable(t)
+	tx, err := conn.Begin(t.Context())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tx.Rollback(context.Background()) })
+
+	for _, itemID := range []string{"prj_1", "prj_2", "prj_1"} {
+		_, err := scrudchange.Record(t.Context(), tx, table, scrudchange.Change{
+			OrganizationID: "org_1", Entity: "project", ItemID: itemID, Action: "ACTION_KIND_MODIFY",
+			Mask: []string{"title"}, After: json.RawMessage(`{"title":"foo"}`),
+		})
+		require.NoError(t, err)
+	}
+
+	list := scrudchange.ListOrganizationChanges[listInput, listOutput](table, listItem)
+	first, err := list(t.Context(), nil, tx, &listInput{org: "org_1", perPage: proto.Int32(2)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"prj_1", "prj_2"}, itemValues(first.items))
+	require.NotNil(t, first.nextCursor)
+
+	second, err := list(t.Context(), nil, tx, &listInput{
+		org: "org_1", perPage: proto.Int32(2), cursor: first.nextCursor,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"prj_1"}, itemValues(second.items))
+	require.Nil(t, second.nextCursor)
+
+	// redacting erases the payloads of all changes of the item, but keeps the changes themselves.
+	require.NoError(t, scrudchange.Redact(t.Context(), tx, table, "project", []string{"prj_1"}))
+
+	var numRedacted, numChanges int
+	require.NoError(t, tx.QueryRow(t.Context(), `SELECT count(*) FILTER (WHERE after IS NULL), count(*) FROM `+
+		pgx.Identifier{table}.Sanitize()).Scan(&numRedacted, &numChanges))
+	require.Equal(t, 2, numRedacted)
+	require.Equal(t, 3, numChanges)
+}
@@ -0,0 +1,130 @@
+package scrudchange
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// ListOrganizationChanges implements the rpc that lists the changes in an organization, newest first. If the
+// input specifies change record ids, only changes to those records are listed. Pagination is keyset-based on
+// the position of the change.
+func ListOrganizationChanges[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetOrganizationId() string
+		GetChangeRecordIds() []string
+		HasPerPage() bool
+		GetPerPage() int32
+		HasCursor() bool
+		GetCursor() []byte
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+		SetNextCursor(cursor []byte)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+](
+	table string,
+	mapf func(ctx context.Context, chg Change) (OITP, error),
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return func(ctx context.Context, _ *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
+		pageSize := int32(100)
+		if inp.HasPerPage() {
+			pageSize = inp.GetPerPage()
+		}
+
+		if pageSize < 1 {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid page size: %d", pageSize))
+		}
+
+		mods := []bob.Mod[*dialect.SelectQuery]{
+			sm.Columns(columns...),
+			sm.From(table),
+			sm.Where(psql.Quote("organization_id").EQ(psql.Arg(inp.GetOrganizationId()))),
+			sm.OrderBy("position").Desc(),
+			sm.Limit(pageSize + 1), // +1 sentinel row
+		}
+
+		if len(inp.GetChangeRecordIds()) > 0 {
+			mods = append(mods, sm.Where(psql.Quote("change_record_ids").OP("&&",
+				psql.Cast(psql.Arg(inp.GetChangeRecordIds()), "uuid[]"))))
+		}
+
+		if inp.HasCursor() {
+			var crs scrudv1.Cursor
+			if err := proto.Unmarshal(inp.GetCursor(), &crs); err != nil || !crs.HasOrderInt64() {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid cursor"))
+			}
+
+			mods = append(mods, sm.Where(psql.Quote("position").LT(psql.Arg(crs.GetOrderInt64()))))
+		}
+
+		sql, args, err := psql.Select(mods...).Build(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("build query: %w", err)
+		}
+
+		rows, err := tx.Query(ctx, sql, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query changes: %w", err)
+		}
+
+		changes, err := pgx.CollectRows(rows, pgx.RowToStructByName[Change])
+		if err != nil {
+			return nil, fmt.Errorf("collect changes: %w", err)
+		}
+
+		var op OP = new(O)
+		if len(changes) > int(pageSize) && len(changes) > 0 {
+			changes = changes[:pageSize] // discard sentinel row
+
+			last := changes[len(changes)-1]
+			crs, err := scrudv1.NewCursor(last.ID, last.Position, false)
+			if err != nil {
+				return nil, fmt.Errorf("init cursor: %w", err)
+			}
+
+			buf, err := proto.Marshal(crs)
+			if err != nil {
+				return nil, fmt.Errorf("marshal cursor: %w", err)
+			}
+
+			op.SetNextCursor(buf)
+		}
+
+		items := make([]OITP, 0, len(changes))
+		for _, chg := range changes {
+			item, err := mapf(ctx, chg)
+			if err != nil {
+				return nil, fmt.Errorf("map change '%s': %w", chg.ID, err)
+			}
+
+			items = append(items, item)
+		}
+
+		op.SetItems(items)
+		return op, nil
+	}
+}
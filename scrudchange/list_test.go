@@ -0,0 +1,120 @@
+package scrudchange_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudchange"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestListOrganizationChanges(t *testing.T) {
+	t.Parallel()
+
+	list := scrudchange.ListOrganizationChanges[listInput, listOutput]("scrud_change", listItem)
+
+	// the sentinel row reveals that there is a next page, which continues below the last position.
+	tx := &fakeTx{results: map[string]fakeResult{"SELECT": changeResult(
+		changeRow("chg_3", 3, "prj_3"), changeRow("chg_2", 2, "prj_2"), changeRow("chg_1", 1, "prj_1"))}}
+	out, err := list(t.Context(), zap.NewNop(), tx, &listInput{org: "org_1", perPage: proto.Int32(2)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"prj_3", "prj_2"}, itemValues(out.items))
+	require.Contains(t, tx.stmts[0].sql, "FROM scrud_change\nWHERE (\"organization_id\" = $1)\n"+
+		"ORDER BY position DESC\nLIMIT 3")
+	require.Equal(t, []any{"org_1"}, tx.stmts[0].args)
+
+	var crs scrudv1.Cursor
+	require.NoError(t, proto.Unmarshal(out.nextCursor, &crs))
+	require.Equal(t, int64(2), crs.GetOrderInt64())
+
+	// the last page has no next cursor, and only lists changes to the requested records.
+	tx = &fakeTx{results: map[string]fakeResult{"SELECT": changeResult(changeRow("chg_1", 1, "prj_1"))}}
+	out, err = list(t.Context(), zap.NewNop(), tx, &listInput{
+		org: "org_1", perPage: proto.Int32(2), cursor: out.nextCursor, recordIDs: []string{"rec_1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"prj_1"}, itemValues(out.items))
+	require.Nil(t, out.nextCursor)
+	require.Contains(t, tx.stmts[0].sql, `("change_record_ids" && (CAST($2 AS uuid[]))) AND ("position" < $3)`)
+	require.Equal(t, []any{"org_1", []string{"rec_1"}, int64(2)}, tx.stmts[0].args)
+}
+
+func TestListOrganizationChangesInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	list := scrudchange.ListOrganizationChanges[listInput, listOutput]("scrud_change", listItem)
+
+	for _, inp := range []*listInput{
+		{org: "org_1", perPage: proto.Int32(0)},
+		{org: "org_1", cursor: []byte{0xff}},
+	} {
+		_, err := list(t.Context(), zap.NewNop(), &fakeTx{}, inp)
+		require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	}
+}
+
+// listItem lists the item id of the change.
+func listItem(_ context.Context, chg scrudchange.Change) (*wrapperspb.StringValue, error) {
+	return wrapperspb.String(chg.ItemID), nil
+}
+
+// changeResult is the result of selecting the changes.
+func changeResult(rows ...[]any) fakeResult {
+	return fakeResult{cols: []string{
+		"id", "position", "organization_id", "entity", "item_id", "change_record_ids", "action", "actor_id", "mask",
+		"before", "after", "created_at", "xact_id",
+	}, rows: rows}
+}
+
+// changeRow is a modification of a project in org_1.
+func changeRow(id string, position int64, itemID string) []any {
+	return []any{
+		id, position, "org_1", "project", itemID, []string{}, "ACTION_KIND_MODIFY", "usr_1", []string{"title"},
+		nil, json.RawMessage(`{}`), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), uint64(position),
+	}
+}
+
+func itemValues(items []*wrapperspb.StringValue) []string {
+	vals := make([]string, 0, len(items))
+	for _, item := range items {
+		vals = append(vals, item.GetValue())
+	}
+
+	return vals
+}
+
+// listInput is the input of the action that lists the changes in an organization.
+type listInput struct {
+	structpb.Struct
+
+	org       string
+	recordIDs []string
+	perPage   *int32
+	cursor    []byte
+}
+
+func (i *listInput) GetOrganizationId() string    { return i.org }
+func (i *listInput) GetChangeRecordIds() []string { return i.recordIDs }
+func (i *listInput) HasPerPage() bool             { return i.perPage != nil }
+func (i *listInput) GetPerPage() int32            { return *i.perPage }
+func (i *listInput) HasCursor() bool              { return i.cursor != nil }
+func (i *listInput) GetCursor() []byte            { return i.cursor }
+
+// listOutput is the output of the action that lists the changes in an organization.
+type listOutput struct {
+	structpb.Struct
+
+	items      []*wrapperspb.StringValue
+	nextCursor []byte
+}
+
+func (o *listOutput) SetItems(items []*wrapperspb.StringValue) { o.items = items }
+func (o *listOutput) SetNextCursor(cursor []byte)              { o.nextCursor = cursor }
@@ -0,0 +1,177 @@
+package scrudchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// watchBatchSize is the maximum number of changes that are read from the table at once.
+	watchBatchSize = 100
+	// watchPollInterval is how long to wait for a notification before reading the changes anyway. Changes that
+	// were held back because an older transaction was still running are not notified again when it ends.
+	watchPollInterval = 5 * time.Second
+)
+
+// WatchPerChange implements a watch action. It sends one message for every change that was captured for the
+// entity in the requested organization, oldest first. It tails the changes table with LISTEN/NOTIFY so the
+// connection must be dedicated to the stream, it returns when the context is cancelled. Every message carries
+// a resume token that the client can provide to resume watching after the change it was sent with.
+//
+// Changes are ordered by the transaction that recorded them, and only read once every older transaction has
+// ended. Positions are handed out when a change is recorded but become visible in the order the transactions
+// commit, so a change with a lower position could otherwise appear after the watcher has passed it.
+func WatchPerChange[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetOrganizationId() string
+		HasResumeToken() bool
+		GetResumeToken() []byte
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetIds(ids []string)
+		SetAction(act scrudv1.ActionKind)
+		SetResumeToken(token []byte)
+	},
+](
+	table string,
+	entName string,
+) func(context.Context, *zap.Logger, *pgx.Conn, IP, func(*O) error) error {
+	return func(ctx context.Context, logs *zap.Logger, conn *pgx.Conn, inp IP, send func(*O) error) error {
+		var after watchCursor
+		if inp.HasResumeToken() {
+			var crs scrudv1.Cursor
+			if err := proto.Unmarshal(inp.GetResumeToken(), &crs); err != nil ||
+				!crs.HasOrderUint64() || !crs.HasPrimaryInt64() {
+				return connect.NewError(connect.CodeInvalidArgument, errors.New("invalid resume token"))
+			}
+
+			after = watchCursor{xactID: crs.GetOrderUint64(), position: crs.GetPrimaryInt64()}
+		}
+
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{table}.Sanitize()); err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+
+		for {
+			// read (and send) changes until we've caught up.
+			for {
+				changes, err := readChanges(ctx, conn, table, entName, inp.GetOrganizationId(), after)
+				if err != nil {
+					return err
+				}
+
+				for _, chg := range changes {
+					msg, err := watchMessage[O, OP](chg)
+					if err != nil {
+						return err
+					}
+
+					if err := send(msg); err != nil {
+						return fmt.Errorf("send: %w", err)
+					}
+
+					after = watchCursor{xactID: chg.XactID, position: chg.Position}
+				}
+
+				if len(changes) < watchBatchSize {
+					break
+				}
+			}
+
+			// wait until a change was captured for the organization we're watching, or until it is time to poll.
+			if err := waitForChanges(ctx, logs, conn, inp.GetOrganizationId()); err != nil {
+				return err
+			} else if ctx.Err() != nil {
+				return nil // the client stopped watching.
+			}
+		}
+	}
+}
+
+// watchCursor points at the last change that was sent to the watcher.
+type watchCursor struct {
+	xactID   uint64
+	position int64
+}
+
+// waitForChanges waits for a notification for the organization, for at most the poll interval.
+func waitForChanges(ctx context.Context, logs *zap.Logger, conn *pgx.Conn, orgID string) error {
+	ctx, cancel := context.WithTimeout(ctx, watchPollInterval)
+	defer cancel()
+
+	for {
+		ntf, err := conn.WaitForNotification(ctx)
+		if ctx.Err() != nil {
+			return nil //nolint:nilerr // the client stopped watching, or it is time to poll.
+		} else if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		if ntf.Payload == orgID {
+			return nil
+		}
+
+		logs.Debug("ignore notification for other organization", zap.String("payload", ntf.Payload))
+	}
+}
+
+// readChanges reads the changes after the cursor. Only changes of transactions that are older than every
+// running transaction are read, no change can become visible before them anymore.
+func readChanges(
+	ctx context.Context, conn *pgx.Conn, table, entName, orgID string, after watchCursor,
+) ([]Change, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf(`SELECT %s FROM %s `+
+		`WHERE organization_id = $1 AND entity = $2 AND (xact_id, position) > ($3::xid8, $4) `+
+		`AND xact_id < pg_snapshot_xmin(pg_current_snapshot()) ORDER BY xact_id, position LIMIT $5`,
+		columnList(), pgx.Identifier{table}.Sanitize()), orgID, entName, after.xactID, after.position,
+		watchBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("query changes: %w", err)
+	}
+
+	changes, err := pgx.CollectRows(rows, pgx.RowToStructByName[Change])
+	if err != nil {
+		return nil, fmt.Errorf("collect changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+func watchMessage[O any, OP interface {
+	*O
+	SetIds(ids []string)
+	SetAction(act scrudv1.ActionKind)
+	SetResumeToken(token []byte)
+}](chg Change) (*O, error) {
+	crs, err := scrudv1.NewKeyCursor(chg.Position, chg.XactID, false)
+	if err != nil {
+		return nil, fmt.Errorf("init resume token: %w", err)
+	}
+
+	token, err := proto.Marshal(crs)
+	if err != nil {
+		return nil, fmt.Errorf("marshal resume token: %w", err)
+	}
+
+	var msg OP = new(O)
+	msg.SetIds([]string{chg.ItemID})
+	msg.SetAction(scrudv1.ActionKind(scrudv1.ActionKind_value[chg.Action]))
+	msg.SetResumeToken(token)
+	return msg, nil
+}
@@ -0,0 +1,163 @@
+package scrudchange_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudchange"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestWatchCommitOrder(t *testing.T) {
+	t.Parallel()
+
+	conn, table := setupChangeTable(t)
+
+	// the first change is recorded in a transaction that is still running when a later change commits.
+	tx1, err := conn.Begin(t.Context())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tx1.Rollback(context.Background()) })
+	recordChange(t, tx1, table, "prj_1")
+
+	conn2 := connectTestDatabase(t)
+	tx2, err := conn2.Begin(t.Context())
+	require.NoError(t, err)
+	recordChange(t, tx2, table, "prj_2")
+	require.NoError(t, tx2.Commit(t.Context()))
+
+	msgs := startWatch(t, table, nil)
+	require.Never(t, func() bool { return len(msgs) > 0 }, time.Second, 50*time.Millisecond)
+
+	require.NoError(t, tx1.Commit(t.Context()))
+
+	first, second := receiveWatch(t, msgs), receiveWatch(t, msgs)
+	require.Equal(t, []string{"prj_1"}, first.ids)
+	require.Equal(t, scrudv1.ActionKind_ACTION_KIND_MODIFY, first.action)
+	require.Equal(t, []string{"prj_2"}, second.ids)
+
+	// resuming after the first change only sends the second one.
+	resumed := startWatch(t, table, first.token)
+	require.Equal(t, []string{"prj_2"}, receiveWatch(t, resumed).ids)
+	require.Never(t, func() bool { return len(resumed) > 0 }, 500*time.Millisecond, 50*time.Millisecond)
+}
+
+func TestWatchInvalidResumeToken(t *testing.T) {
+	t.Parallel()
+
+	// the token is checked before the connection is used.
+	watch := scrudchange.WatchPerChange[watchInput, watchOutput]("scrud_change", "project")
+	err := watch(t.Context(), zap.NewNop(), nil, &watchInput{org: "org_1", token: []byte{0xff}},
+		func(*watchOutput) error { return nil })
+	require.ErrorContains(t, err, "invalid resume token")
+}
+
+// startWatch watches the changes of org_1 on a dedicated connection until the test ends.
+func startWatch(t *testing.T, table string, token []byte) chan *watchOutput {
+	t.Helper()
+
+	conn := connectTestDatabase(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	msgs, done := make(chan *watchOutput, 10), make(chan error, 1)
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	watch := scrudchange.WatchPerChange[watchInput, watchOutput](table, "project")
+	go func() {
+		done <- watch(ctx, zap.NewNop(), conn, &watchInput{org: "org_1", token: token},
+			func(msg *watchOutput) error { msgs <- msg; return nil })
+	}()
+
+	return msgs
+}
+
+func receiveWatch(t *testing.T, msgs chan *watchOutput) *watchOutput {
+	t.Helper()
+
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(10 * time.Second):
+		t.Fatal("no change was watched")
+		return nil
+	}
+}
+
+// recordChange records a modification of the item in org_1, and notifies the watchers when the transaction commits.
+func recordChange(t *testing.T, tx pgx.Tx, table, itemID string) {
+	t.Helper()
+
+	_, err := scrudchange.Record(t.Context(), tx, table, scrudchange.Change{
+		OrganizationID: "org_1", Entity: "project", ItemID: itemID, Action: "ACTION_KIND_MODIFY",
+	})
+	require.NoError(t, err)
+	_, err = tx.Exec(t.Context(), `SELECT pg_notify($1, $2)`, table, "org_1")
+	require.NoError(t, err)
+}
+
+// setupChangeTable creates a changes table that is dropped when the test ends.
+func setupChangeTable(t *testing.T) (*pgx.Conn, string) {
+	t.Helper()
+
+	conn := connectTestDatabase(t)
+	table := fmt.Sprintf("scrud_change_%d", time.Now().UnixNano())
+	for _, stmt := range scrudchange.TableDDL(table) {
+		_, err := conn.Exec(t.Context(), stmt)
+		require.NoError(t, err)
+	}
+
+	t.Cleanup(func() {
+		_, _ = conn.Exec(context.Background(), "DROP TABLE "+pgx.Identifier{table}.Sanitize())
+	})
+
+	return conn, table
+}
+
+// connectTestDatabase connects to the database of SCRUD_TEST_DATABASE_URL, the test is skipped without it.
+func connectTestDatabase(t *testing.T) *pgx.Conn {
+	t.Helper()
+
+	dsn := os.Getenv("SCRUD_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("SCRUD_TEST_DATABASE_URL is not set")
+	}
+
+	conn, err := pgx.Connect(t.Context(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close(context.Background()) })
+
+	return conn
+}
+
+// watchInput is the input of a watch action.
+type watchInput struct {
+	structpb.Struct
+
+	org   string
+	token []byte
+}
+
+func (i *watchInput) GetOrganizationId() string { return i.org }
+func (i *watchInput) HasResumeToken() bool      { return i.token != nil }
+func (i *watchInput) GetResumeToken() []byte    { return i.token }
+
+// watchOutput is a message that is sent by a watch action.
+type watchOutput struct {
+	structpb.Struct
+
+	ids    []string
+	action scrudv1.ActionKind
+	token  []byte
+}
+
+func (o *watchOutput) SetIds(ids []string)              { o.ids = ids }
+func (o *watchOutput) SetAction(act scrudv1.ActionKind) { o.action = act }
+func (o *watchOutput) SetResumeToken(token []byte)      { o.token = token }
@@ -0,0 +1,131 @@
+// Package scrudoutbox implements a transactional outbox for publishing events about entity mutations.
+package scrudoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// DefaultTable is the name of the outbox table, unless configured otherwise.
+const DefaultTable = "scrud_outbox"
+
+// TableDDL returns the statements that create the outbox table.
+func TableDDL(table string) []string {
+	tbl := pgx.Identifier{table}.Sanitize()
+
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	position bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	id uuid NOT NULL DEFAULT gen_random_uuid(),
+	source text NOT NULL,
+	type text NOT NULL,
+	subject text NOT NULL,
+	time timestamptz NOT NULL DEFAULT clock_timestamp(),
+	data jsonb
+)`, tbl),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (subject, position)`,
+			pgx.Identifier{table + "_subject_position_idx"}.Sanitize(), tbl),
+	}
+}
+
+// Event is a CloudEvents (v1.0) formatted event, it marshals to the structured JSON format.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Data is the payload of the events that are written for mutations.
+type Data struct {
+	ID     string   `json:"id"`
+	Action string   `json:"action"`
+	Mask   []string `json:"mask,omitempty"`
+}
+
+// Writer writes an event into the outbox for every item that is mutated through the runtime helpers. The
+// events are written in the same transaction as the mutation. It is used as an interceptor:
+//
+//	scrudruntime.CreatePerItem(createf, scrudruntime.WithInterceptor(writer))
+type Writer struct {
+	// name of the entity the events are written for.
+	Entity string
+	// the CloudEvents source attribute, e.g. the name of the service.
+	Source string
+	// table the events are written to, defaults to DefaultTable.
+	Table string
+}
+
+// InterceptMutation implements scrudruntime.Interceptor.
+func (w Writer) InterceptMutation(
+	ctx context.Context,
+	_ *zap.Logger,
+	tx pgx.Tx,
+	mut scrudruntime.Mutation,
+	next scrudruntime.MutationFunc,
+) ([]string, error) {
+	ids, err := next(ctx)
+	if err != nil {
+		return ids, err
+	}
+
+	for _, id := range ids {
+		data, err := json.Marshal(Data{ID: id, Action: mut.Action.String(), Mask: mut.Masks[id].GetPaths()})
+		if err != nil {
+			return nil, fmt.Errorf("marshal event data: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (source, type, subject, data) VALUES ($1, $2, $3, $4)`,
+			pgx.Identifier{tableOrDefault(w.Table)}.Sanitize()),
+			w.Source, EventType(w.Entity, mut.Action), Subject(w.Entity, id), data,
+		); err != nil {
+			return nil, fmt.Errorf("insert outbox event for '%s': %w", id, err)
+		}
+	}
+
+	return ids, nil
+}
+
+// EventType returns the CloudEvents type for a mutation of an entity, e.g: "project.modified".
+func EventType(entName string, act scrudv1.ActionKind) string {
+	verb := strings.ToLower(strings.TrimPrefix(act.String(), "ACTION_KIND_"))
+	switch act {
+	case scrudv1.ActionKind_ACTION_KIND_CREATE,
+		scrudv1.ActionKind_ACTION_KIND_REMOVE,
+		scrudv1.ActionKind_ACTION_KIND_RESTORE,
+		scrudv1.ActionKind_ACTION_KIND_PURGE:
+		verb += "d"
+	case scrudv1.ActionKind_ACTION_KIND_MODIFY:
+		verb = "modified"
+	case scrudv1.ActionKind_ACTION_KIND_UPSERT:
+		verb = "upserted"
+	default:
+	}
+
+	return strings.ToLower(entName) + "." + verb
+}
+
+// Subject returns the CloudEvents subject for an item of an entity. Events are relayed in-order per subject.
+func Subject(entName, id string) string {
+	return strings.ToLower(entName) + "/" + id
+}
+
+func tableOrDefault(table string) string {
+	if table == "" {
+		return DefaultTable
+	}
+
+	return table
+}
@@ -0,0 +1,51 @@
+package scrudoutbox_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudoutbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventType(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		act scrudv1.ActionKind
+		exp string
+	}{
+		{scrudv1.ActionKind_ACTION_KIND_CREATE, "project.created"},
+		{scrudv1.ActionKind_ACTION_KIND_MODIFY, "project.modified"},
+		{scrudv1.ActionKind_ACTION_KIND_REMOVE, "project.removed"},
+		{scrudv1.ActionKind_ACTION_KIND_RESTORE, "project.restored"},
+		{scrudv1.ActionKind_ACTION_KIND_UPSERT, "project.upserted"},
+		{scrudv1.ActionKind_ACTION_KIND_PURGE, "project.purged"},
+	} {
+		require.Equal(t, tt.exp, scrudoutbox.EventType("Project", tt.act))
+	}
+
+	require.Equal(t, "project/prj_1", scrudoutbox.Subject("Project", "prj_1"))
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	t.Parallel()
+
+	var pub scrudoutbox.MemoryPublisher
+	require.NoError(t, pub.Publish(t.Context(), scrudoutbox.Event{ID: "1"}))
+	require.NoError(t, pub.Publish(t.Context(), scrudoutbox.Event{ID: "2"}))
+
+	evs := pub.Events()
+	require.Len(t, evs, 2)
+	require.Equal(t, "1", evs[0].ID)
+
+	buf, err := json.Marshal(scrudoutbox.Event{
+		SpecVersion: "1.0", ID: "1", Source: "svc", Type: "project.created",
+		Time: time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"specversion":"1.0","id":"1","source":"svc","type":"project.created",`+
+		`"time":"2025-07-24T12:00:00Z"}`, string(buf))
+}
@@ -0,0 +1,162 @@
+package scrudoutbox
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Publisher publishes events to a broker. It must only return nil once the broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// MemoryPublisher keeps published events in memory, it is meant for testing.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Publish implements Publisher.
+func (p *MemoryPublisher) Publish(_ context.Context, ev Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, ev)
+	return nil
+}
+
+// Events returns all events published so far.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.events)
+}
+
+// Relay drains the outbox into a publisher. Events are published at-least-once: if the transaction that
+// removes them from the outbox fails they will be published again. Multiple relays may run concurrently, the
+// events of a single subject (entity item) are always published in the order they were written.
+type Relay struct {
+	// database the outbox is in.
+	DB scrudruntime.Beginner
+	// publisher that receives the events.
+	Publisher Publisher
+	// logs for the relay.
+	Logs *zap.Logger
+	// table of the outbox, defaults to DefaultTable.
+	Table string
+	// maximum number of events that are published per transaction, defaults to 100.
+	BatchSize int
+	// how long to wait before polling an empty outbox again, defaults to one second.
+	Interval time.Duration
+}
+
+// Run drains the outbox until the context is cancelled.
+func (r Relay) Run(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		num, err := r.Drain(ctx)
+		if err != nil {
+			r.logs().Error("failed to drain outbox", zap.Error(err))
+		}
+
+		// only wait if there was nothing (left) to publish.
+		if err == nil && num > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Drain publishes one batch of events and returns how many were published. Only the oldest event of each
+// subject is considered so events of a subject are never published out-of-order, not even by concurrent
+// relays. Calling Drain again continues with later events.
+func (r Relay) Drain(ctx context.Context) (int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+
+	defer func() { _ = tx.Rollback(ctx) }() // no-op after commit
+
+	tbl := pgx.Identifier{tableOrDefault(r.Table)}.Sanitize()
+	rows, err := tx.Query(ctx, fmt.Sprintf(`SELECT o.position, o.id, o.source, o.type, o.subject, o.time, o.data `+
+		`FROM %[1]s o WHERE NOT EXISTS (SELECT 1 FROM %[1]s p WHERE p.subject = o.subject AND p.position < o.position) `+
+		`ORDER BY o.position LIMIT $1 FOR UPDATE SKIP LOCKED`, tbl), r.batchSize())
+	if err != nil {
+		return 0, fmt.Errorf("select events: %w", err)
+	}
+
+	type row struct {
+		Position int64
+		Event
+	}
+
+	var batch []row
+	var ev row
+	if _, err := pgx.ForEachRow(rows,
+		[]any{&ev.Position, &ev.ID, &ev.Source, &ev.Type, &ev.Subject, &ev.Time, &ev.Data},
+		func() error {
+			ev.SpecVersion, ev.DataContentType = "1.0", "application/json"
+			batch = append(batch, ev)
+			ev = row{}
+			return nil
+		},
+	); err != nil {
+		return 0, fmt.Errorf("scan events: %w", err)
+	}
+
+	// publish in order, and stop at the first failure so later events of the same subject are not published
+	// before the failed one.
+	var published []int64
+	var pubErr error
+	for _, row := range batch {
+		if pubErr = r.Publisher.Publish(ctx, row.Event); pubErr != nil {
+			pubErr = fmt.Errorf("publish event '%s': %w", row.ID, pubErr)
+			break
+		}
+
+		published = append(published, row.Position)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE position = ANY($1)`, tbl), published); err != nil {
+		return 0, fmt.Errorf("delete published events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+
+	r.logs().Debug("drained outbox", zap.Int("num_published", len(published)), zap.Int("num_selected", len(batch)))
+	return len(published), pubErr
+}
+
+func (r Relay) batchSize() int {
+	if r.BatchSize <= 0 {
+		return 100
+	}
+
+	return r.BatchSize
+}
+
+func (r Relay) logs() *zap.Logger {
+	if r.Logs == nil {
+		return zap.NewNop()
+	}
+
+	return r.Logs
+}
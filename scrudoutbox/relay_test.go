@@ -0,0 +1,167 @@
+package scrudoutbox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/advdv/scrud/scrudoutbox"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayDrainOrder(t *testing.T) {
+	t.Parallel()
+
+	conn, table := setupOutbox(t, "a1", "a2", "b1", "a3")
+	var pub scrudoutbox.MemoryPublisher
+	relay := scrudoutbox.Relay{DB: conn, Publisher: &pub, Table: table}
+
+	// only the oldest event of each subject is published per drain.
+	for _, exp := range [][]string{{"a1", "b1"}, {"a2"}, {"a3"}, {}} {
+		num, err := relay.Drain(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, len(exp), num)
+	}
+
+	require.Equal(t, []string{"a1", "b1", "a2", "a3"}, eventTypes(pub.Events()))
+	require.Zero(t, countEvents(t, conn, table))
+}
+
+func TestRelayDrainConcurrently(t *testing.T) {
+	t.Parallel()
+
+	conn, table := setupOutbox(t, "a1", "a2", "b1")
+
+	// the first relay is stuck publishing the head of the first subject.
+	publishing, release := make(chan struct{}), make(chan struct{})
+	var blocked scrudoutbox.MemoryPublisher
+	relay1 := scrudoutbox.Relay{
+		DB: connectTestDatabase(t), Table: table, BatchSize: 1,
+		Publisher: publishFunc(func(ctx context.Context, ev scrudoutbox.Event) error {
+			close(publishing)
+			<-release
+			return blocked.Publish(ctx, ev)
+		}),
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := relay1.Drain(t.Context())
+		done <- err
+	}()
+	<-publishing
+
+	// a concurrent relay skips the locked event, and the events that come after it in the same subject.
+	var pub scrudoutbox.MemoryPublisher
+	relay2 := scrudoutbox.Relay{DB: conn, Publisher: &pub, Table: table}
+	num, err := relay2.Drain(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 1, num)
+	require.Equal(t, []string{"b1"}, eventTypes(pub.Events()))
+
+	close(release)
+	require.NoError(t, <-done)
+	require.Equal(t, []string{"a1"}, eventTypes(blocked.Events()))
+
+	num, err = relay2.Drain(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 1, num)
+	require.Equal(t, []string{"b1", "a2"}, eventTypes(pub.Events()))
+}
+
+func TestRelayDrainRetry(t *testing.T) {
+	t.Parallel()
+
+	conn, table := setupOutbox(t, "a1", "b1", "b2")
+
+	var pub scrudoutbox.MemoryPublisher
+	fail := true
+	relay := scrudoutbox.Relay{DB: conn, Table: table, Publisher: publishFunc(
+		func(ctx context.Context, ev scrudoutbox.Event) error {
+			if ev.Type == "b1" && fail {
+				fail = false
+				return errors.New("broker unavailable")
+			}
+
+			return pub.Publish(ctx, ev)
+		})}
+
+	// the events before the failure are removed from the outbox, the failed one is published again.
+	num, err := relay.Drain(t.Context())
+	require.ErrorContains(t, err, "broker unavailable")
+	require.Equal(t, 1, num)
+	require.Equal(t, 2, countEvents(t, conn, table))
+
+	for _, exp := range []int{1, 1, 0} {
+		num, err = relay.Drain(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, exp, num)
+	}
+
+	require.Equal(t, []string{"a1", "b1", "b2"}, eventTypes(pub.Events()))
+}
+
+type publishFunc func(ctx context.Context, ev scrudoutbox.Event) error
+
+func (f publishFunc) Publish(ctx context.Context, ev scrudoutbox.Event) error { return f(ctx, ev) }
+
+// setupOutbox creates an outbox with events of the given types, the first letter of a type is its subject.
+func setupOutbox(t *testing.T, types ...string) (*pgx.Conn, string) {
+	t.Helper()
+
+	conn := connectTestDatabase(t)
+	table := fmt.Sprintf("scrud_outbox_%d", time.Now().UnixNano())
+	for _, stmt := range scrudoutbox.TableDDL(table) {
+		_, err := conn.Exec(t.Context(), stmt)
+		require.NoError(t, err)
+	}
+
+	t.Cleanup(func() {
+		_, _ = conn.Exec(context.Background(), "DROP TABLE "+pgx.Identifier{table}.Sanitize())
+	})
+
+	for _, typ := range types {
+		_, err := conn.Exec(t.Context(), fmt.Sprintf(`INSERT INTO %s (source, type, subject) VALUES ($1, $2, $3)`,
+			pgx.Identifier{table}.Sanitize()), "test", typ, typ[:1])
+		require.NoError(t, err)
+	}
+
+	return conn, table
+}
+
+func countEvents(t *testing.T, conn *pgx.Conn, table string) (num int) {
+	t.Helper()
+
+	require.NoError(t, conn.QueryRow(t.Context(),
+		"SELECT count(*) FROM "+pgx.Identifier{table}.Sanitize()).Scan(&num))
+	return num
+}
+
+func eventTypes(evs []scrudoutbox.Event) []string {
+	types := make([]string, 0, len(evs))
+	for _, ev := range evs {
+		types = append(types, ev.Type)
+	}
+
+	return types
+}
+
+// connectTestDatabase connects to the database of SCRUD_TEST_DATABASE_URL, the test is skipped without it.
+func connectTestDatabase(t *testing.T) *pgx.Conn {
+	t.Helper()
+
+	dsn := os.Getenv("SCRUD_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("SCRUD_TEST_DATABASE_URL is not set")
+	}
+
+	conn, err := pgx.Connect(t.Context(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close(context.Background()) })
+
+	return conn
+}
@@ -0,0 +1,158 @@
+package scrudruntime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/um"
+	"go.uber.org/zap"
+)
+
+// CascadeOriginColumn holds the id of the parent row that a child row was archived together with. It is NULL
+// for rows that were archived independently, so restoring the parent doesn't restore them.
+const CascadeOriginColumn = "archived_cascade_from"
+
+// Cascade describes a child table that is archived and restored together with its parent.
+type Cascade struct {
+	// base table of the child entity.
+	Table string
+	// column of the child table that holds the id of the parent.
+	ForeignKey string
+	// primary key column of the child table, defaults to "id".
+	KeyColumn string
+	// children of the child, that cascade further.
+	Children []Cascade
+}
+
+// CascadeArchive archives the live rows of the children (and their children) of the archived parents. The rows
+// are tagged with the id of their parent so that CascadeRestore only restores what was archived along with it.
+func CascadeArchive(ctx context.Context, tx pgx.Tx, children []Cascade, parentIDs []string) error {
+	return cascade(ctx, tx, children, parentIDs, nil, func(table, fk, origin, key string) string {
+		return fmt.Sprintf(`UPDATE %s SET archived_at = now(), %s = %s WHERE %s = ANY($1) AND archived_at IS NULL `+
+			`RETURNING %s`, table, origin, fk, fk, key)
+	})
+}
+
+// CascadeRestore restores the rows of the children (and their children) that were archived together with the
+// restored parents. Rows that were archived independently remain archived.
+func CascadeRestore(ctx context.Context, tx pgx.Tx, children []Cascade, parentIDs []string) error {
+	return cascade(ctx, tx, children, parentIDs, nil, func(table, fk, origin, key string) string {
+		return fmt.Sprintf(`UPDATE %s SET archived_at = NULL, %s = NULL WHERE %s = ANY($1) AND %s = %s `+
+			`AND archived_at IS NOT NULL RETURNING %s`, table, origin, fk, origin, fk, key)
+	})
+}
+
+// ClearCascadeOrigin detaches the rows with the given ids (or keys) from the parent they were archived together
+// with. It must be called when the rows are archived or restored independently, so that restoring the parent
+// doesn't restore them later on. The key columns of the table default to "id".
+func ClearCascadeOrigin[K ItemKey](ctx context.Context, tx pgx.Tx, table string, ids []K, keyCols ...string) error {
+	if len(ids) < 1 {
+		return nil
+	}
+
+	match, err := keyIn(keyColumnsOrID(keyCols), ids)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	sql, args, err := psql.Update(
+		um.Table(psql.Quote(table)), um.SetCol(CascadeOriginColumn).To(psql.Raw("NULL")), um.Where(match),
+	).Build(ctx)
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("clear cascade origin of '%s': %w", table, err)
+	}
+
+	return nil
+}
+
+// cascade executes the statement for each child and recurses with the ids of the affected rows. The path holds
+// the tables that are currently being cascaded into, to detect cycles in the relationships.
+func cascade(
+	ctx context.Context, tx pgx.Tx, children []Cascade, parentIDs []string, path []string,
+	stmt func(table, fk, origin, key string) string,
+) error {
+	if len(parentIDs) < 1 {
+		return nil
+	}
+
+	for _, child := range children {
+		if slices.Contains(path, child.Table) {
+			return fmt.Errorf("cascade into '%s' is cyclic: %v", child.Table, path)
+		}
+
+		keyCol := child.KeyColumn
+		if keyCol == "" {
+			keyCol = "id"
+		}
+
+		rows, err := tx.Query(ctx, stmt(pgx.Identifier{child.Table}.Sanitize(),
+			pgx.Identifier{child.ForeignKey}.Sanitize(), pgx.Identifier{CascadeOriginColumn}.Sanitize(),
+			pgx.Identifier{keyCol}.Sanitize()), parentIDs)
+		if err != nil {
+			return fmt.Errorf("cascade into '%s': %w", child.Table, err)
+		}
+
+		// the ids are passed on as strings, which are encoded as the type of the foreign keys they match.
+		ids, err := collectKeys(rows, []string{keyCol})
+		if err != nil {
+			return fmt.Errorf("collect cascaded ids of '%s': %w", child.Table, err)
+		}
+
+		if err := cascade(ctx, tx, child.Children, ids, append(path, child.Table), stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Cascader is an interceptor that cascades removes and restores of an entity to its children, in the same
+// transaction. For example:
+//
+//	scrudruntime.RemovePerBatch(removef, scrudruntime.WithInterceptor(scrudruntime.Cascader{Children: ...}))
+type Cascader struct {
+	// children of the entity.
+	Children []Cascade
+	// base table of the entity, only set if the entity is itself the child in a cascade. Its rows are then
+	// detached from the parent they were archived with when they are removed or restored independently.
+	Table string
+	// primary key columns of the entity's table, defaults to "id".
+	KeyColumns []string
+}
+
+// InterceptMutation implements Interceptor.
+func (c Cascader) InterceptMutation(
+	ctx context.Context, _ *zap.Logger, tx pgx.Tx, mut Mutation, next MutationFunc,
+) ([]string, error) {
+	ids, err := next(ctx)
+	if err != nil {
+		return ids, err
+	}
+
+	switch mut.Action {
+	case scrudv1.ActionKind_ACTION_KIND_REMOVE, scrudv1.ActionKind_ACTION_KIND_RESTORE:
+	default:
+		return ids, nil
+	}
+
+	if c.Table != "" {
+		if err := ClearCascadeOrigin(ctx, tx, c.Table, ids, c.KeyColumns...); err != nil {
+			return ids, err
+		}
+	}
+
+	if mut.Action == scrudv1.ActionKind_ACTION_KIND_REMOVE {
+		return ids, CascadeArchive(ctx, tx, c.Children, ids)
+	}
+
+	return ids, CascadeRestore(ctx, tx, c.Children, ids)
+}
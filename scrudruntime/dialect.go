@@ -0,0 +1,167 @@
+package scrudruntime
+
+import (
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/mysql"
+	mysqldialect "github.com/stephenafamo/bob/dialect/mysql/dialect"
+	mysqlsm "github.com/stephenafamo/bob/dialect/mysql/sm"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+	"github.com/stephenafamo/bob/dialect/sqlite"
+	sqlitedialect "github.com/stephenafamo/bob/dialect/sqlite/dialect"
+	sqlitesm "github.com/stephenafamo/bob/dialect/sqlite/sm"
+	"github.com/stephenafamo/bob/expr"
+)
+
+// Dialect is the SQL dialect that queries are produced for.
+type Dialect string
+
+const (
+	// DialectPostgres produces queries for PostgreSQL, the default.
+	DialectPostgres Dialect = "postgres"
+	// DialectSQLite produces queries for SQLite.
+	DialectSQLite Dialect = "sqlite"
+	// DialectMySQL produces queries for MySQL.
+	DialectMySQL Dialect = "mysql"
+)
+
+// PaginateSQLiteSelectMods is PaginateSelectMods for SQLite. SQLite supports the row values that the cursors
+// compare with since version 3.15.
+func PaginateSQLiteSelectMods[I pageInput](
+	inp I,
+	baseTableName string,
+	opts ...PaginateOption,
+) ([]bob.Mod[*sqlitedialect.SelectQuery], func(rows []map[string]any) ([]string, []byte, []byte, error), error) {
+	plan, err := planPage(inp, baseTableName, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return renderPage(plan, sqliteSelect), plan.pageRows, nil
+}
+
+// PaginateMySQLSelectMods is PaginateSelectMods for MySQL.
+func PaginateMySQLSelectMods[I pageInput](
+	inp I,
+	baseTableName string,
+	opts ...PaginateOption,
+) ([]bob.Mod[*mysqldialect.SelectQuery], func(rows []map[string]any) ([]string, []byte, []byte, error), error) {
+	plan, err := planPage(inp, baseTableName, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return renderPage(plan, mysqlSelect), plan.pageRows, nil
+}
+
+// PaginateQuery returns the query for a page in the dialect, and the function that turns the queried rows
+// into the ids and cursors. It is used by code that is independent of the dialect, see ListPage.
+func PaginateQuery[I pageInput](
+	d Dialect,
+	inp I,
+	baseTableName string,
+	opts ...PaginateOption,
+) (bob.Query, func(rows []map[string]any) ([]string, []byte, []byte, error), error) {
+	plan, err := planPage(inp, baseTableName, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch d {
+	case DialectSQLite:
+		return sqlite.Select(renderPage(plan, sqliteSelect)...), plan.pageRows, nil
+	case DialectMySQL:
+		return mysql.Select(renderPage(plan, mysqlSelect)...), plan.pageRows, nil
+	default:
+		return psql.Select(renderPage(plan, psqlSelect)...), plan.pageRows, nil
+	}
+}
+
+// selectBuilder builds the select mods of a dialect.
+type selectBuilder[Q any] struct {
+	columns func(cols ...any) bob.Mod[Q]
+	from    func(table string) bob.Mod[Q]
+	where   func(e bob.Expression) bob.Mod[Q]
+	orderBy func(col string, desc bool) bob.Mod[Q]
+	limit   func(n int64) bob.Mod[Q]
+	group   func(exprs ...bob.Expression) bob.Expression
+}
+
+var psqlSelect = selectBuilder[*dialect.SelectQuery]{
+	columns: sm.Columns,
+	from:    func(table string) bob.Mod[*dialect.SelectQuery] { return sm.From(table) },
+	where:   func(e bob.Expression) bob.Mod[*dialect.SelectQuery] { return sm.Where(e) },
+	orderBy: orderBy,
+	limit:   func(n int64) bob.Mod[*dialect.SelectQuery] { return sm.Limit(n) },
+	group:   func(exprs ...bob.Expression) bob.Expression { return psql.Group(exprs...) },
+}
+
+var sqliteSelect = selectBuilder[*sqlitedialect.SelectQuery]{
+	columns: sqlitesm.Columns,
+	from:    func(table string) bob.Mod[*sqlitedialect.SelectQuery] { return sqlitesm.From(table) },
+	where:   func(e bob.Expression) bob.Mod[*sqlitedialect.SelectQuery] { return sqlitesm.Where(e) },
+	orderBy: func(col string, desc bool) bob.Mod[*sqlitedialect.SelectQuery] {
+		if desc {
+			return sqlitesm.OrderBy(col).Desc()
+		}
+		return sqlitesm.OrderBy(col)
+	},
+	limit: func(n int64) bob.Mod[*sqlitedialect.SelectQuery] { return sqlitesm.Limit(n) },
+	group: func(exprs ...bob.Expression) bob.Expression { return sqlite.Group(exprs...) },
+}
+
+var mysqlSelect = selectBuilder[*mysqldialect.SelectQuery]{
+	columns: mysqlsm.Columns,
+	from:    func(table string) bob.Mod[*mysqldialect.SelectQuery] { return mysqlsm.From(table) },
+	where:   func(e bob.Expression) bob.Mod[*mysqldialect.SelectQuery] { return mysqlsm.Where(e) },
+	orderBy: func(col string, desc bool) bob.Mod[*mysqldialect.SelectQuery] {
+		if desc {
+			return mysqlsm.OrderBy(col).Desc()
+		}
+		return mysqlsm.OrderBy(col)
+	},
+	limit: mysqlsm.Limit,
+	group: func(exprs ...bob.Expression) bob.Expression { return mysql.Group(exprs...) },
+}
+
+// renderPage returns the mods for the page in the dialect of the builder.
+func renderPage[Q any](p pagePlan, b selectBuilder[Q]) []bob.Mod[Q] {
+	cols := make([]any, 0, len(p.keyCols)+1)
+	for _, col := range p.keyCols {
+		cols = append(cols, col)
+	}
+
+	// Build the (base) query, optionally add cursor's where clause.
+	mods := []bob.Mod[Q]{
+		b.columns(append(cols, p.sortCol)...),
+		b.orderBy(p.sortCol, p.desc),
+	}
+	for _, col := range p.keyCols {
+		mods = append(mods, b.orderBy(col, p.desc)) // always tie‑break on pk
+	}
+	mods = append(mods, b.limit(int64(p.pageSize)+1)) // +1 sentinel row
+
+	if p.anchor != nil {
+		// (<sortCol,id) > (<anchorValue,anchorID>)  or  <
+		lhs := make([]bob.Expression, 0, len(p.keyCols)+1)
+		for _, col := range append([]string{p.sortCol}, p.keyCols...) {
+			lhs = append(lhs, expr.Quote(col))
+		}
+
+		op := ">"
+		if p.desc {
+			op = "<"
+		}
+
+		mods = append(mods, b.where(expr.OP(op, b.group(lhs...), expr.ArgGroup(p.anchor...))))
+	}
+
+	// Show the live rows, the archived rows or all rows, as selected by the archive filter, within the scopes.
+	mods = append(mods, b.from(p.from))
+	for _, scope := range p.scopes {
+		mods = append(mods, b.where(expr.OP("=", expr.Quote(scope.Column), expr.Arg(scope.Value))))
+	}
+
+	return mods
+}
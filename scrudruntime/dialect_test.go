@@ -0,0 +1,89 @@
+package scrudruntime_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stephenafamo/bob"
+	"github.com/stretchr/testify/require"
+)
+
+type pageInput struct {
+	perPage int32
+	cursor  []byte
+}
+
+func (pageInput) HasSortBy() bool       { return false }
+func (pageInput) GetSortBy() string     { return "" }
+func (pageInput) GetSortDesc() bool     { return false }
+func (i pageInput) HasPerPage() bool    { return i.perPage > 0 }
+func (i pageInput) GetPerPage() int32   { return i.perPage }
+func (i pageInput) HasCursor() bool     { return len(i.cursor) > 0 }
+func (i pageInput) GetCursor() []byte   { return i.cursor }
+func (pageInput) GetShowArchived() bool { return false }
+
+func TestPaginateQueryDialects(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		dialect scrudruntime.Dialect
+		exp     string
+	}{
+		{scrudruntime.DialectPostgres, `WHERE ("created_at", "id") > ($1, $2) AND "organization_id" = $3`},
+		{scrudruntime.DialectSQLite, `WHERE ("created_at", "id") > (?1, ?2) AND "organization_id" = ?3`},
+		{scrudruntime.DialectMySQL, "WHERE (`created_at`, `id`) > (?, ?) AND `organization_id` = ?"},
+	} {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			t.Parallel()
+
+			tx := &pageTx{rows: []map[string]any{
+				{"id": "foo_1", "created_at": int64(1)},
+				{"id": "foo_2", "created_at": int64(2)},
+			}}
+
+			ids, next, _, err := scrudruntime.ListPage(t.Context(), tx, tt.dialect, pageInput{perPage: 1}, "foo")
+			require.NoError(t, err)
+			require.Equal(t, []string{"foo_1"}, ids)
+			require.NotEmpty(t, next)
+
+			query, _, err := scrudruntime.PaginateQuery(tt.dialect, pageInput{perPage: 1, cursor: next}, "foo",
+				scrudruntime.WithScope(scrudruntime.Scope{Column: "organization_id", Value: "org_1"}))
+			require.NoError(t, err)
+
+			sql, args, err := bob.Build(t.Context(), query)
+			require.NoError(t, err)
+			require.Contains(t, sql, tt.exp)
+			require.Contains(t, sql, "foo_live")
+			require.Equal(t, []any{int64(1), "foo_1", "org_1"}, args)
+		})
+	}
+}
+
+// pageTx returns the same rows for every query.
+type pageTx struct{ rows []map[string]any }
+
+func (tx *pageTx) Exec(context.Context, string, ...any) (int64, error) { return 0, nil }
+
+func (tx *pageTx) Query(context.Context, string, ...any) (scrudruntime.Rows, error) {
+	return &pageRows{rows: tx.rows, idx: -1}, nil
+}
+
+type pageRows struct {
+	rows []map[string]any
+	idx  int
+}
+
+func (r *pageRows) Columns() []string { return []string{"id", "created_at"} }
+func (r *pageRows) Next() bool        { r.idx++; return r.idx < len(r.rows) }
+func (r *pageRows) Err() error        { return nil }
+func (r *pageRows) Close() error      { return nil }
+
+func (r *pageRows) Scan(dest ...any) error {
+	for i, col := range r.Columns() {
+		ptr, _ := dest[i].(*any)
+		*ptr = r.rows[r.idx][col]
+	}
+
+	return nil
+}
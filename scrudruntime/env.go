@@ -0,0 +1,104 @@
+package scrudruntime
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Env is the executor of actions with the pgx and zap signature: the transaction the action runs in, and the
+// data of the request. The actor is not part of it, it is carried by the Session of the context. The helpers
+// have variants (e.g. CreatePerItemExec) that are parameterised over the executor type, so applications can use
+// Env, embed it in their own type, or use a type without pgx or zap.
+type Env struct {
+	// transaction the action runs in.
+	Tx pgx.Tx
+	// logs for the action.
+	Logs *zap.Logger
+	// Clock returns the current time, defaults to time.Now.
+	Clock func() time.Time
+	// id of the request, if known.
+	RequestID string
+	// Tracer starts a span that is ended by calling the returned function. Nothing is traced if nil.
+	Tracer func(ctx context.Context, name string) (context.Context, func())
+}
+
+// Executor is implemented by executors that provide a pgx transaction and logs. The options for interceptors
+// and idempotency keys require it, since they write to the transaction of the action. Env implements it.
+type Executor interface {
+	PgxTx() pgx.Tx
+	Logger() *zap.Logger
+}
+
+// PgxTx implements Executor.
+func (e Env) PgxTx() pgx.Tx { return e.Tx }
+
+// Logger implements Executor, it never returns nil.
+func (e Env) Logger() *zap.Logger {
+	if e.Logs == nil {
+		return zap.NewNop()
+	}
+
+	return e.Logs
+}
+
+// Now returns the current time of the clock.
+func (e Env) Now() time.Time {
+	if e.Clock == nil {
+		return time.Now()
+	}
+
+	return e.Clock()
+}
+
+// StartSpan starts a span with the tracer, the returned function ends it.
+func (e Env) StartSpan(ctx context.Context, name string) (context.Context, func()) {
+	if e.Tracer == nil {
+		return ctx, func() {}
+	}
+
+	return e.Tracer(ctx, name)
+}
+
+// FromPgx adapts an action with the pgx and zap signature to one that runs with an Env.
+func FromPgx[IP, OP any](
+	f func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error),
+) func(context.Context, Env, IP) (OP, error) {
+	return func(ctx context.Context, env Env, inp IP) (OP, error) {
+		return f(ctx, env.Logs, env.Tx, inp)
+	}
+}
+
+// ToPgx adapts an action that runs with an Env to the pgx and zap signature. The Env only carries the
+// transaction and the logs, use WithEnv to add the data of the request.
+func ToPgx[IP, OP any](
+	f func(context.Context, Env, IP) (OP, error),
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
+		env, _ := ctx.Value(envKey{}).(Env)
+		env.Tx, env.Logs = tx, logs
+		return f(ctx, env, inp)
+	}
+}
+
+type envKey struct{}
+
+// WithEnv returns a context with the Env that ToPgx starts from, e.g. to carry the request id from a connect
+// interceptor to actions that are adapted with ToPgx. Its transaction and logs are replaced.
+func WithEnv(ctx context.Context, env Env) context.Context {
+	return context.WithValue(ctx, envKey{}, env)
+}
+
+// startSpan starts a span if the executor supports tracing.
+func startSpan(ctx context.Context, exec any, name string) (context.Context, func()) {
+	tracer, ok := exec.(interface {
+		StartSpan(ctx context.Context, name string) (context.Context, func())
+	})
+	if !ok {
+		return ctx, func() {}
+	}
+
+	return tracer.StartSpan(ctx, name)
+}
@@ -0,0 +1,74 @@
+package scrudruntime_test
+
+import (
+	"context"
+	"testing"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestToPgxEnv(t *testing.T) {
+	t.Parallel()
+
+	logs := zap.NewNop()
+	action := scrudruntime.ToPgx(func(_ context.Context, env scrudruntime.Env, inp string) (string, error) {
+		require.Same(t, logs, env.Logs)
+		return env.RequestID + ":" + inp, nil
+	})
+
+	ctx := scrudruntime.WithEnv(t.Context(), scrudruntime.Env{RequestID: "req_1"})
+	out, err := action(ctx, logs, nil, "foo")
+	require.NoError(t, err)
+	require.Equal(t, "req_1:foo", out)
+
+	out, err = scrudruntime.FromPgx(action)(t.Context(), scrudruntime.Env{Logs: logs}, "bar")
+	require.NoError(t, err)
+	require.Equal(t, ":bar", out)
+}
+
+func TestWithInterceptorEnv(t *testing.T) {
+	t.Parallel()
+
+	var muts []scrudruntime.Mutation
+	icp := interceptorFunc(func(
+		ctx context.Context, _ *zap.Logger, _ pgx.Tx, mut scrudruntime.Mutation, next scrudruntime.MutationFunc,
+	) ([]string, error) {
+		muts = append(muts, mut)
+		return next(ctx)
+	})
+
+	// the options of the pgx helpers are constructed without type arguments.
+	remove := scrudruntime.RemovePerBatch[removeInput, emptypb.Empty](
+		func(context.Context, *zap.Logger, pgx.Tx, []string) error { return nil },
+		scrudruntime.WithInterceptor(icp), scrudruntime.WithKeyFields("id"))
+	_, err := remove(t.Context(), zap.NewNop(), nil, &removeInput{ids: []string{"prj_1"}})
+	require.NoError(t, err)
+	require.Equal(t, []scrudruntime.Mutation{
+		{Action: scrudv1.ActionKind_ACTION_KIND_REMOVE, IDs: []string{"prj_1"}},
+	}, muts)
+}
+
+type interceptorFunc func(
+	ctx context.Context, logs *zap.Logger, tx pgx.Tx, mut scrudruntime.Mutation, next scrudruntime.MutationFunc,
+) ([]string, error)
+
+func (f interceptorFunc) InterceptMutation(
+	ctx context.Context, logs *zap.Logger, tx pgx.Tx, mut scrudruntime.Mutation, next scrudruntime.MutationFunc,
+) ([]string, error) {
+	return f(ctx, logs, tx, mut, next)
+}
+
+// removeInput is the input of a remove action.
+type removeInput struct {
+	structpb.Struct
+
+	ids []string
+}
+
+func (i *removeInput) GetIds() []string { return i.ids }
@@ -0,0 +1,178 @@
+package scrudruntime
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultIdempotencyTable is the name of the table that idempotency keys are stored in, unless configured
+// otherwise.
+const DefaultIdempotencyTable = "scrud_idempotency"
+
+// IdempotencyTableDDL returns the statements that create the table for storing idempotency keys.
+func IdempotencyTableDDL(table string) []string {
+	tbl := pgx.Identifier{table}.Sanitize()
+
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	scope text NOT NULL,
+	key text NOT NULL,
+	request_hash bytea NOT NULL,
+	response bytea NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	expires_at timestamptz NOT NULL,
+	PRIMARY KEY (scope, key)
+)`, tbl),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (expires_at)`,
+			pgx.Identifier{table + "_expires_at_idx"}.Sanitize(), tbl),
+	}
+}
+
+// IdempotencyStore records the response for every idempotency key so a retried request is answered with the
+// original response instead of being executed again. It uses the same transaction as the request.
+type IdempotencyStore struct {
+	// table the keys are stored in, defaults to DefaultIdempotencyTable.
+	Table string
+	// how long keys are remembered, defaults to 24 hours.
+	TTL time.Duration
+}
+
+// WithIdempotency makes the helper remember the response for requests that carry an idempotency key. The
+// scope separates the keys of different rpcs, for example by using the full method name.
+func WithIdempotency(store IdempotencyStore, scope string) Option[Env] {
+	return WithIdempotencyExec[Env](store, scope)
+}
+
+// WithIdempotencyExec is WithIdempotency for helpers that run with an executor of type E.
+func WithIdempotencyExec[E Executor](store IdempotencyStore, scope string) Option[E] {
+	return func(o *options[E]) {
+		o.idempotency = &idempotency{store: store, scope: scope}
+		o.executor = asExecutor[E]
+	}
+}
+
+type idempotency struct {
+	store IdempotencyStore
+	scope string
+}
+
+// DeleteExpired removes all keys that have expired.
+func (s IdempotencyStore) DeleteExpired(ctx context.Context, tx pgx.Tx) (int64, error) {
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE expires_at <= now()`, s.table()))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired keys: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (s IdempotencyStore) table() string {
+	if s.Table == "" {
+		return pgx.Identifier{DefaultIdempotencyTable}.Sanitize()
+	}
+
+	return pgx.Identifier{s.Table}.Sanitize()
+}
+
+func (s IdempotencyStore) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return time.Hour * 24
+	}
+
+	return s.TTL
+}
+
+// idempotent runs the request, unless the request carries an idempotency key that was seen before. In that
+// case the response of the original request is returned.
+func idempotent[O any, OP interface {
+	*O
+	proto.Message
+}](
+	ctx context.Context,
+	tx pgx.Tx,
+	idm *idempotency,
+	inp proto.Message,
+	run func() (OP, error),
+) (OP, error) {
+	keyed, ok := inp.(interface{ GetIdempotencyKey() string })
+	if idm == nil || !ok || keyed.GetIdempotencyKey() == "" {
+		return run()
+	}
+
+	key, tbl := keyed.GetIdempotencyKey(), idm.store.table()
+	hash, err := requestHash(inp)
+	if err != nil {
+		return nil, err
+	}
+
+	var storedHash, storedResp []byte
+	switch err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT request_hash, response FROM %s `+
+		`WHERE scope = $1 AND key = $2 AND expires_at > now()`, tbl), idm.scope, key,
+	).Scan(&storedHash, &storedResp); {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("lookup idempotency key: %w", err)
+	case !bytes.Equal(storedHash, hash):
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("idempotency key '%s' was already used for a different request", key))
+	default:
+		var op OP = new(O)
+		if err := proto.Unmarshal(storedResp, op); err != nil {
+			return nil, fmt.Errorf("unmarshal stored response: %w", err)
+		}
+
+		return op, nil
+	}
+
+	op, err := run()
+	if err != nil {
+		return op, err
+	}
+
+	resp, err := proto.Marshal(op)
+	if err != nil {
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+
+	// a concurrent request with the same key will block on the primary key until this transaction finishes. If
+	// it commits, the (unexpired) row will conflict and we abort so the client retries and gets the response.
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %[1]s (scope, key, request_hash, response, expires_at) `+
+		`VALUES ($1, $2, $3, $4, now() + $5::interval) ON CONFLICT (scope, key) DO UPDATE `+
+		`SET request_hash = EXCLUDED.request_hash, response = EXCLUDED.response, created_at = now(), `+
+		`expires_at = EXCLUDED.expires_at WHERE %[1]s.expires_at <= now()`, tbl),
+		idm.scope, key, hash, resp, idm.store.ttl())
+	if err != nil {
+		return nil, fmt.Errorf("store idempotency key: %w", err)
+	}
+
+	if tag.RowsAffected() < 1 {
+		return nil, connect.NewError(connect.CodeAborted,
+			fmt.Errorf("concurrent request with idempotency key '%s'", key))
+	}
+
+	return op, nil
+}
+
+// requestHash hashes the request without its idempotency key.
+func requestHash(inp proto.Message) ([]byte, error) {
+	inp = proto.Clone(inp)
+	if fd := inp.ProtoReflect().Descriptor().Fields().ByName("idempotency_key"); fd != nil {
+		inp.ProtoReflect().Clear(fd)
+	}
+
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(inp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request for hashing: %w", err)
+	}
+
+	sum := sha256.Sum256(buf)
+	return sum[:], nil
+}
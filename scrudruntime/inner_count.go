@@ -0,0 +1,126 @@
+package scrudruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+)
+
+// DefaultCountThreshold is the number of rows up to which totals are counted exactly.
+const DefaultCountThreshold = 10_000
+
+// CountTotal counts the rows a list request pages through. The filters must be the same (e.g. ScopeMods)
+// mods that are added to the mods from PaginateSelectMods. Rows are counted exactly up to the threshold, above
+// it the planner's row estimate is returned and estimate is true. A threshold of zero uses the default.
+func CountTotal[
+	// request's input message, see ArchiveFilterOf.
+	I any,
+](
+	ctx context.Context,
+	tx pgx.Tx,
+	inp I,
+	baseTableName string,
+	threshold int64,
+	filters ...bob.Mod[*dialect.SelectQuery],
+) (total int64, estimate bool, err error) {
+	if threshold <= 0 {
+		threshold = DefaultCountThreshold
+	}
+
+	base := append([]bob.Mod[*dialect.SelectQuery]{
+		sm.Columns(psql.Raw("1")),
+		fromArchiveFilter(baseTableName, ArchiveFilterOf(inp)),
+	}, filters...)
+
+	// count at most threshold+1 rows, so large tables are never scanned fully.
+	sql, args, err := bob.Build(ctx, psql.Select(
+		sm.Columns(psql.Raw("count(*)")),
+		sm.From(psql.Select(append(base, sm.Limit(threshold+1))...)).As("counted"),
+	))
+	if err != nil {
+		return 0, false, fmt.Errorf("build count query: %w", err)
+	}
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return 0, false, fmt.Errorf("count rows: %w", err)
+	}
+
+	if total <= threshold {
+		return total, false, nil
+	}
+
+	sql, args, err = bob.Build(ctx, psql.Select(base...))
+	if err != nil {
+		return 0, false, fmt.Errorf("build estimate query: %w", err)
+	}
+
+	var plan []byte
+	if err := tx.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+sql, args...).Scan(&plan); err != nil {
+		return 0, false, fmt.Errorf("explain rows: %w", err)
+	}
+
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &plans); err != nil {
+		return 0, false, fmt.Errorf("unmarshal query plan: %w", err)
+	}
+
+	if len(plans) < 1 {
+		return 0, false, errors.New("query plan is empty")
+	}
+
+	// the estimate is never reported below what was counted exactly.
+	return max(int64(plans[0].Plan.Rows), threshold+1), true, nil
+}
+
+// ArchiveFilterOf returns the archive filter of an input. Inputs either have an "archive_filter" field, a
+// "show_archived" field to only show archived rows, or a "consider_archived" field to consider all rows.
+func ArchiveFilterOf(inp any) scrudv1.ArchiveFilter {
+	switch inp := inp.(type) {
+	case interface{ GetArchiveFilter() scrudv1.ArchiveFilter }:
+		if inp.GetArchiveFilter() == scrudv1.ArchiveFilter_ARCHIVE_FILTER_UNSPECIFIED {
+			return scrudv1.ArchiveFilter_ARCHIVE_FILTER_LIVE
+		}
+
+		return inp.GetArchiveFilter()
+	case interface{ GetShowArchived() bool }:
+		if inp.GetShowArchived() {
+			return scrudv1.ArchiveFilter_ARCHIVE_FILTER_ARCHIVED
+		}
+	case interface{ GetConsiderArchived() bool }:
+		if inp.GetConsiderArchived() {
+			return scrudv1.ArchiveFilter_ARCHIVE_FILTER_ALL
+		}
+	}
+
+	return scrudv1.ArchiveFilter_ARCHIVE_FILTER_LIVE
+}
+
+// fromArchiveFilter selects from the view with either the live rows or the archived rows, or from the base
+// table for all rows.
+func fromArchiveFilter(baseTableName string, filter scrudv1.ArchiveFilter) bob.Mod[*dialect.SelectQuery] {
+	return sm.From(archiveFilterTable(baseTableName, filter))
+}
+
+// archiveFilterTable returns the view (or table) with the rows that pass the filter.
+func archiveFilterTable(baseTableName string, filter scrudv1.ArchiveFilter) string {
+	switch filter {
+	case scrudv1.ArchiveFilter_ARCHIVE_FILTER_ALL:
+		return baseTableName
+	case scrudv1.ArchiveFilter_ARCHIVE_FILTER_ARCHIVED:
+		return baseTableName + "_archived"
+	default:
+		return baseTableName + "_live"
+	}
+}
@@ -7,170 +7,258 @@ import (
 	"connectrpc.com/connect"
 	scrudv1 "github.com/advdv/scrud/scrud/v1"
 	"github.com/stephenafamo/bob"
-	"github.com/stephenafamo/bob/dialect/psql"
 	"github.com/stephenafamo/bob/dialect/psql/dialect"
 	"github.com/stephenafamo/bob/dialect/psql/sm"
 	"google.golang.org/protobuf/proto"
 )
 
-// PaginateSelectMods will setup a bob query mode for generic cursor-based pagination via maps.
-//
-//nolint:gocognit
-func PaginateSelectMods[
-	// request's input message
-	I interface {
-		HasSortBy() bool
-		GetSortBy() string
-		GetSortDesc() bool
-		HasPerPage() bool
-		GetPerPage() int32
-		HasCursor() bool
-		GetCursor() []byte
-		GetShowArchived() bool
-	},
-](
+// PaginateSelectMods will setup a bob query mode for generic cursor-based pagination via maps. Rows can be
+// restricted to the scopes of nested entities with WithScope. See PaginateSQLiteSelectMods and
+// PaginateMySQLSelectMods for other dialects.
+func PaginateSelectMods[I pageInput](
 	inp I,
 	baseTableName string,
+	opts ...PaginateOption,
 ) ([]bob.Mod[*dialect.SelectQuery], func(rows []map[string]any) ([]string, []byte, []byte, error), error) {
-	// determine ordering column, direction and page size.
-	sortCol := "created_at"
+	plan, err := planPage(inp, baseTableName, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return renderPage(plan, psqlSelect), plan.pageRows, nil
+}
+
+// pageInput is the input of a request that pages through rows.
+type pageInput interface {
+	HasSortBy() bool
+	GetSortBy() string
+	GetSortDesc() bool
+	HasPerPage() bool
+	GetPerPage() int32
+	HasCursor() bool
+	GetCursor() []byte
+}
+
+// pagePlan describes the query for a page independent of the SQL dialect.
+type pagePlan struct {
+	from      string
+	sortCol   string
+	keyCols   []string
+	desc      bool
+	pageSize  int32
+	backwards bool
+	anchor    []any // sort value and key parts, nil if the page is not anchored.
+	scopes    []Scope
+}
+
+// planPage determines the ordering column, direction and page size, and decodes the cursor of the input.
+func planPage[I pageInput](inp I, baseTableName string, opts ...PaginateOption) (plan pagePlan, err error) {
+	o := applyPaginateOptions(opts)
+	plan = pagePlan{
+		from:     archiveFilterTable(baseTableName, ArchiveFilterOf(inp)),
+		sortCol:  "created_at",
+		keyCols:  o.keyCols,
+		desc:     inp.GetSortDesc(), // NOTE: may be flipped later
+		pageSize: 100,
+		scopes:   o.scopes,
+	}
+
 	if inp.HasSortBy() {
-		sortCol = inp.GetSortBy()
+		plan.sortCol = inp.GetSortBy()
 	}
 
-	desc := inp.GetSortDesc() // NOTE: may be flipped later
-	pageSize := int32(100)
 	if inp.HasPerPage() {
-		pageSize = inp.GetPerPage()
+		plan.pageSize = inp.GetPerPage()
 	}
 
 	// Decode the incoming cursor (if any)
-	var sortValue any  // sorting value of hte cursor row
-	var sortID string  // id of cursor row
-	var backwards bool // true means “scan *before* anchor”
-	if inp.HasCursor() {
-		c, err := decodeCursor(inp.GetCursor())
-		if err != nil {
-			return nil, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("decode cursor: %w", err))
-		}
-		sortValue, sortID = c.OrderValue(), c.GetPrimaryId()
-		backwards = c.GetIsBackwards()
-		if backwards {
-			desc = !desc // invert the SQL ORDER BY direction
-		}
+	sortValue, sortKey, backwards, anchored, err := decodePageCursor(inp)
+	if err != nil {
+		return plan, err
 	}
 
-	// Build the (base) query, optionally add cursor's where clause.
-	mods := []bob.Mod[*dialect.SelectQuery]{
-		sm.Columns("id", sortCol),
-		orderBy(sortCol, desc),
-		orderBy("id", desc),    // always tie‑break on pk
-		sm.Limit(pageSize + 1), // +1 sentinel row
+	plan.backwards = backwards
+	if backwards {
+		plan.desc = !plan.desc // invert the SQL ORDER BY direction
 	}
-	if sortValue != nil {
-		// (<sortCol,id) > (<anchorValue,anchorID>)  or  <
-		lhs := psql.Group(psql.Quote(sortCol), psql.Quote("id"))
-		rhs := psql.ArgGroup(sortValue, sortID)
-		if desc {
-			mods = append(mods, sm.Where(lhs.LT(rhs)))
-		} else {
-			mods = append(mods, sm.Where(lhs.GT(rhs)))
-		}
+
+	if anchored {
+		plan.anchor = append([]any{sortValue}, keyArgs(sortKey)...)
 	}
 
-	// Show either the live rows, or the archived rows.
-	if inp.GetShowArchived() {
-		mods = append(mods, sm.From(baseTableName+"_archived"))
-	} else {
-		mods = append(mods, sm.From(baseTableName+"_live"))
+	return plan, nil
+}
+
+// pageRows turns the rows that were queried for the plan into the ids, and the cursors of the neighbouring pages.
+func (p pagePlan) pageRows(rows []map[string]any) ([]string, []byte, []byte, error) {
+	return pageRows(rows, p.sortCol, p.keyCols, p.pageSize, p.backwards, p.anchor != nil)
+}
+
+// pageRows turns the rows of a page (including the sentinel row) into the ids, and the cursors of the
+// neighbouring pages.
+//
+//nolint:gocognit
+func pageRows(rows []map[string]any, sortCol string, keyCols []string, pageSize int32, backwards, anchored bool) (
+	ids []string, nextCursor []byte, prevCursor []byte, err error,
+) {
+	// We added a sential row to check for more. Discard it for the rest of the processing.
+	hasMore := len(rows) > int(pageSize)
+	if hasMore {
+		rows = rows[:pageSize] // discard sentinel row
 	}
 
-	return mods, func(rows []map[string]any) (ids []string, nextCursor []byte, prevCursor []byte, err error) {
-		// We added a sential row to check for more. Discard it for the rest of the processing.
-		hasMore := len(rows) > int(pageSize)
-		if hasMore {
-			rows = rows[:pageSize] // discard sentinel row
-		}
+	// If we fetched “backwards”, put the slice in natural order for the
+	// client.  The cursors are built *before* we reverse so we can still
+	// access first & last in SQL order.
+	if len(rows) > 0 { //nolint:nestif
+		first := rows[0]
+		last := rows[len(rows)-1]
 
-		// If we fetched “backwards”, put the slice in natural order for the
-		// client.  The cursors are built *before* we reverse so we can still
-		// access first & last in SQL order.
-		isFirstPage := !inp.HasCursor() // true only for the very first request
-		if len(rows) > 0 {              //nolint:nestif
-			first := rows[0]
-			last := rows[len(rows)-1]
-
-			// nextCursor ⇢ rows after “last in client order”
-			// prevCursor ⇢ rows before “first in client order”
-			if backwards {
-				// we walked BACKWARDS so:
-				//   • a *previous* page exists if hasMore
-				//   • a *next*  page always exists (client can go forward again)
-				nextCursor, err = mapEncodeCursor(first, sortCol, false) // forward
+		// nextCursor ⇢ rows after “last in client order”
+		// prevCursor ⇢ rows before “first in client order”
+		if backwards {
+			// we walked BACKWARDS so:
+			//   • a *previous* page exists if hasMore
+			//   • a *next*  page exists unless we started at the last row
+			if anchored {
+				nextCursor, err = mapEncodeCursor(first, sortCol, keyCols, false) // forward
 				if err != nil {
 					return nil, nil, nil, fmt.Errorf("encode next cursor: %w", err)
 				}
+			}
 
-				if hasMore {
-					prevCursor, err = mapEncodeCursor(last, sortCol, true)
-					if err != nil {
-						return nil, nil, nil, fmt.Errorf("encode prev cursor: %w", err)
-					}
+			if hasMore {
+				prevCursor, err = mapEncodeCursor(last, sortCol, keyCols, true)
+				if err != nil {
+					return nil, nil, nil, fmt.Errorf("encode prev cursor: %w", err)
 				}
-				slices.Reverse(rows)
-			} else {
-				// we walked FORWARS so:
-				//   • a *next* page exists if hasMore
-				//   • a *previous* page always exists once we have any row
-				if hasMore {
-					nextCursor, err = mapEncodeCursor(last, sortCol, false)
-					if err != nil {
-						return nil, nil, nil, fmt.Errorf("encode next cursor: %w", err)
-					}
+			}
+			slices.Reverse(rows)
+		} else {
+			// we walked FORWARS so:
+			//   • a *next* page exists if hasMore
+			//   • a *previous* page exists unless we started at the first row
+			if hasMore {
+				nextCursor, err = mapEncodeCursor(last, sortCol, keyCols, false)
+				if err != nil {
+					return nil, nil, nil, fmt.Errorf("encode next cursor: %w", err)
 				}
-				if !isFirstPage {
-					prevCursor, err = mapEncodeCursor(first, sortCol, true)
-					if err != nil {
-						return nil, nil, nil, fmt.Errorf("encode prev cursor: %w", err)
-					}
+			}
+			if anchored {
+				prevCursor, err = mapEncodeCursor(first, sortCol, keyCols, true)
+				if err != nil {
+					return nil, nil, nil, fmt.Errorf("encode prev cursor: %w", err)
 				}
 			}
 		}
+	}
 
-		// Finally, turn them into ids to fit the contract.
-		ids = make([]string, len(rows))
-		for i, r := range rows {
-			ids[i], err = getRowID(r)
-			if err != nil {
-				return nil, nil, nil, fmt.Errorf("get final row ids: %w", err)
-			}
+	// Finally, turn them into ids to fit the contract.
+	ids = make([]string, len(rows))
+	for i, r := range rows {
+		ids[i], err = getRowID(r, keyCols)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("get final row ids: %w", err)
 		}
+	}
 
-		return ids, nextCursor, prevCursor, nil
-	}, nil
+	return ids, nextCursor, prevCursor, nil
 }
 
-func getRowID(row map[string]any) (string, error) {
-	v, ok := row["id"].(string)
-	if !ok {
-		return "", fmt.Errorf("row map has no <string> value for 'id' column, got: %T", row["id"])
+// decodePageCursor decodes the cursor of the input. Without an anchor (sortValue and sortKey) the page starts at
+// the first row, or the last row if backwards is true.
+func decodePageCursor(inp interface {
+	HasCursor() bool
+	GetCursor() []byte
+},
+) (sortValue, sortKey any, backwards, anchored bool, err error) {
+	if !inp.HasCursor() {
+		// without a cursor, the input may ask to start at the last page.
+		fe, ok := inp.(interface{ GetFromEnd() bool })
+		return nil, nil, ok && fe.GetFromEnd(), false, nil
+	}
+
+	c, err := decodeCursor(inp.GetCursor())
+	if err != nil {
+		return nil, nil, false, false, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("decode cursor: %w", err))
+	}
+
+	if c.GetIsEdge() {
+		return nil, nil, c.GetIsBackwards(), false, nil
+	}
+
+	return c.OrderValue(), c.PrimaryKey(), c.GetIsBackwards(), true, nil
+}
+
+// EdgeCursors returns the cursors that start listing at the first page, and at the last page.
+func EdgeCursors() (first, last []byte, err error) {
+	if first, err = proto.Marshal(scrudv1.NewEdgeCursor(false)); err != nil {
+		return nil, nil, fmt.Errorf("marshal first cursor: %w", err)
+	}
+
+	if last, err = proto.Marshal(scrudv1.NewEdgeCursor(true)); err != nil {
+		return nil, nil, fmt.Errorf("marshal last cursor: %w", err)
+	}
+
+	return first, last, nil
+}
+
+// getRowID returns the id of a row, composite keys are encoded with CompositeKeyString.
+func getRowID(row map[string]any, keyCols []string) (string, error) {
+	parts := make([]any, 0, len(keyCols))
+	for _, col := range keyCols {
+		parts = append(parts, row[col])
+	}
+
+	if len(parts) > 1 {
+		return CompositeKeyString(parts...)
+	}
+
+	v, err := KeyString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("row map has no valid value for '%s' column: %w", keyCols[0], err)
 	}
 
 	return v, nil
 }
 
-func mapEncodeCursor(row map[string]any, sortCol string, backwards bool) ([]byte, error) {
+// rowKey returns the key of the row as it is stored in a cursor.
+func rowKey(row map[string]any, keyCols []string) (any, error) {
+	parts := make([]any, 0, len(keyCols))
+	for _, col := range keyCols {
+		// integer keys are kept as integers, so the database compares them as such.
+		switch key := row[col].(type) {
+		case int64, int32, int:
+			parts = append(parts, key)
+		default:
+			str, err := KeyString(key)
+			if err != nil {
+				return nil, fmt.Errorf("row map has no valid value for '%s' column: %w", col, err)
+			}
+
+			parts = append(parts, str)
+		}
+	}
+
+	if len(parts) > 1 {
+		return parts, nil
+	}
+
+	return parts[0], nil
+}
+
+func mapEncodeCursor(row map[string]any, sortCol string, keyCols []string, backwards bool) ([]byte, error) {
 	val, ok := row[sortCol]
 	if !ok {
 		return nil, fmt.Errorf("row map has no value for column: %s", sortCol)
 	}
 
-	id, err := getRowID(row)
+	key, err := rowKey(row, keyCols)
 	if err != nil {
-		return nil, fmt.Errorf("get row id: %w", err)
+		return nil, fmt.Errorf("get row key: %w", err)
 	}
 
-	c, err := scrudv1.NewCursor(id, val, backwards)
+	c, err := scrudv1.NewKeyCursor(key, val, backwards)
 	if err != nil {
 		return nil, fmt.Errorf("init cursor: %w", err)
 	}
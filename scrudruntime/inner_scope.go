@@ -0,0 +1,126 @@
+package scrudruntime
+
+import (
+	"fmt"
+
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Scope restricts the rows of an entity to those with the value in the column, e.g. an organization or a
+// project the entity is nested in.
+type Scope struct {
+	// id column that scopes the rows.
+	Column string
+	// value the column must be equal to.
+	Value any
+}
+
+// ScopesOf returns the scopes for the (string) scope fields of a message, typically the input of a list
+// request. The fields are named after their columns. It returns an error if the message doesn't have one of
+// the fields, rows would otherwise be listed across all scopes.
+func ScopesOf(msg proto.Message, fields ...string) ([]Scope, error) {
+	refl := msg.ProtoReflect()
+	scopes := make([]Scope, 0, len(fields))
+	for _, name := range fields {
+		field := refl.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return nil, fmt.Errorf("message '%s' has no scope field '%s'", refl.Descriptor().FullName(), name)
+		}
+
+		scopes = append(scopes, Scope{Column: name, Value: refl.Get(field).String()})
+	}
+
+	return scopes, nil
+}
+
+// ScopeMods returns the equality predicates for the scopes.
+func ScopeMods(scopes ...Scope) []bob.Mod[*dialect.SelectQuery] {
+	mods := make([]bob.Mod[*dialect.SelectQuery], 0, len(scopes))
+	for _, scope := range scopes {
+		mods = append(mods, sm.Where(psql.Quote(scope.Column).EQ(psql.Arg(scope.Value))))
+	}
+
+	return mods
+}
+
+// PaginateOption configures the mods of PaginateSelectMods and SearchSelectMods.
+type PaginateOption func(*paginateOptions)
+
+type paginateOptions struct {
+	scopes  []Scope
+	keyCols []string
+}
+
+// WithScope restricts the paginated rows to the scopes.
+func WithScope(scopes ...Scope) PaginateOption {
+	return func(o *paginateOptions) {
+		o.scopes = append(o.scopes, scopes...)
+	}
+}
+
+// WithKeyColumn sets the primary key column that pages tie-break on, and that the ids are read from. Defaults
+// to "id".
+func WithKeyColumn(col string) PaginateOption {
+	return WithKeyColumns(col)
+}
+
+// WithKeyColumns sets the columns of a composite primary key, pages tie-break on each of them. The ids are
+// encoded with CompositeKeyString.
+func WithKeyColumns(cols ...string) PaginateOption {
+	return func(o *paginateOptions) {
+		o.keyCols = cols
+	}
+}
+
+// keyColumns returns the key columns for selecting them.
+func (o paginateOptions) keyColumns() []any {
+	cols := make([]any, 0, len(o.keyCols))
+	for _, col := range o.keyCols {
+		cols = append(cols, col)
+	}
+
+	return cols
+}
+
+// keyExprs returns the key columns for comparing them with the cursor.
+func (o paginateOptions) keyExprs() []bob.Expression {
+	exprs := make([]bob.Expression, 0, len(o.keyCols))
+	for _, col := range o.keyCols {
+		exprs = append(exprs, psql.Quote(col))
+	}
+
+	return exprs
+}
+
+// keyOrderBy returns the ordering on the key columns.
+func (o paginateOptions) keyOrderBy(desc bool) []bob.Mod[*dialect.SelectQuery] {
+	mods := make([]bob.Mod[*dialect.SelectQuery], 0, len(o.keyCols))
+	for _, col := range o.keyCols {
+		mods = append(mods, orderBy(col, desc))
+	}
+
+	return mods
+}
+
+// keyArgs returns the arguments for a key from a cursor, composite keys have an argument for each part.
+func keyArgs(key any) []any {
+	if parts, ok := key.([]any); ok {
+		return parts
+	}
+
+	return []any{key}
+}
+
+func applyPaginateOptions(opts []PaginateOption) paginateOptions {
+	o := paginateOptions{keyCols: []string{"id"}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
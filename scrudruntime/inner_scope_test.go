@@ -0,0 +1,33 @@
+package scrudruntime_test
+
+import (
+	"testing"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestScopesOf(t *testing.T) {
+	t.Parallel()
+
+	msg := &descriptorpb.FieldDescriptorProto{Name: proto.String("org_1"), Extendee: proto.String("prj_1")}
+	scopes, err := scrudruntime.ScopesOf(msg, "name", "extendee")
+	require.NoError(t, err)
+
+	mods := append([]bob.Mod[*dialect.SelectQuery]{sm.Columns("id"), sm.From("foo")},
+		scrudruntime.ScopeMods(scopes...)...)
+	sql, args, err := bob.Build(t.Context(), psql.Select(mods...))
+	require.NoError(t, err)
+	require.Contains(t, sql, `WHERE ("name" = $1) AND ("extendee" = $2)`)
+	require.Equal(t, []any{"org_1", "prj_1"}, args)
+
+	_, err = scrudruntime.ScopesOf(msg, "name", "organization_id")
+	require.EqualError(t, err,
+		"message 'google.protobuf.FieldDescriptorProto' has no scope field 'organization_id'")
+}
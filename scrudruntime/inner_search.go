@@ -0,0 +1,80 @@
+package scrudruntime
+
+import (
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+)
+
+const (
+	// SearchVectorColumn is the generated tsvector column that search actions match against.
+	SearchVectorColumn = "search_vector"
+	// DefaultSearchLanguage is the text search configuration used when none is configured.
+	DefaultSearchLanguage = "simple"
+)
+
+// searchRankCol is the alias of the rank in the selected rows, it is the order value of the cursors.
+const searchRankCol = "search_rank"
+
+// SearchSelectMods will setup a bob query mod for full-text search, ranked by ts_rank. The most relevant rows
+// come first and pages are walked with cursors that have the rank as their order value. The language must be
+// the text search configuration of the search column, e.g. the SearchLanguage of scrudschema.TablesFromConfig.
+func SearchSelectMods[
+	// request's input message
+	I interface {
+		GetQuery() string
+		HasPerPage() bool
+		GetPerPage() int32
+		HasCursor() bool
+		GetCursor() []byte
+	},
+](
+	inp I,
+	baseTableName string,
+	language string,
+	opts ...PaginateOption,
+) ([]bob.Mod[*dialect.SelectQuery], func(rows []map[string]any) ([]string, []byte, []byte, error), error) {
+	if language == "" {
+		language = DefaultSearchLanguage
+	}
+
+	pageSize := int32(100)
+	if inp.HasPerPage() {
+		pageSize = inp.GetPerPage()
+	}
+
+	o := applyPaginateOptions(opts)
+	sortValue, sortKey, backwards, anchored, err := decodePageCursor(inp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desc := !backwards // most relevant first, unless walking backwards.
+	query := psql.F("websearch_to_tsquery", psql.Arg(language), psql.Arg(inp.GetQuery()))
+	rank := psql.F("ts_rank", psql.Quote(SearchVectorColumn), query)
+
+	mods := []bob.Mod[*dialect.SelectQuery]{
+		sm.Columns(append(o.keyColumns(), psql.Group(rank).As(searchRankCol))...),
+		sm.Where(psql.Raw("? @@ ?", psql.Quote(SearchVectorColumn), query)),
+		orderBy(searchRankCol, desc),
+		sm.Limit(pageSize + 1),
+		fromArchiveFilter(baseTableName, ArchiveFilterOf(inp)),
+	}
+	mods = append(mods, o.keyOrderBy(desc)...) // always tie‑break on pk
+	mods = append(mods, ScopeMods(o.scopes...)...)
+
+	if anchored {
+		lhs := psql.Group(append([]bob.Expression{psql.Group(rank)}, o.keyExprs()...)...)
+		rhs := psql.ArgGroup(append([]any{sortValue}, keyArgs(sortKey)...)...)
+		if desc {
+			mods = append(mods, sm.Where(lhs.LT(rhs)))
+		} else {
+			mods = append(mods, sm.Where(lhs.GT(rhs)))
+		}
+	}
+
+	return mods, func(rows []map[string]any) ([]string, []byte, []byte, error) {
+		return pageRows(rows, searchRankCol, o.keyCols, pageSize, backwards, anchored)
+	}, nil
+}
@@ -0,0 +1,131 @@
+package scrudruntime
+
+import (
+	"context"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Mutation describes a change that is executed through one of the mutation helpers.
+type Mutation struct {
+	// the kind of action that performs the mutation.
+	Action scrudv1.ActionKind
+	// ids of the items that will be mutated. It is empty for creates since ids are only known afterwards.
+	IDs []string
+	// the update masks by item id, only set for modifications.
+	Masks map[string]*fieldmaskpb.FieldMask
+}
+
+// MutationFunc performs the actual mutation and returns the ids of the affected items.
+type MutationFunc func(ctx context.Context) ([]string, error)
+
+// Interceptor wraps the execution of mutations by the helpers. It runs in the same transaction as the
+// mutation itself so anything it writes is committed (or rolled back) atomically with it.
+type Interceptor interface {
+	InterceptMutation(
+		ctx context.Context, logs *zap.Logger, tx pgx.Tx, mut Mutation, next MutationFunc,
+	) ([]string, error)
+}
+
+// Option configures the helpers that run with an executor of type E. The constructors return options for Env,
+// their Exec variants (e.g. WithInterceptorExec) for other executors. Options that write to the transaction of
+// the action require the executor to implement Executor, which is checked when the option is constructed.
+type Option[E any] func(*options[E])
+
+type options[E any] struct {
+	interceptors []Interceptor
+	idempotency  *idempotency
+	mapKeyMasks  bool
+	keyFields    []string
+	versionTable string
+	// executor is set by the options that require the executor to implement Executor.
+	executor func(E) Executor
+}
+
+// WithInterceptor adds an interceptor that wraps every mutation executed by the helper. Interceptors run
+// in the order they are provided, the first one being the outermost.
+func WithInterceptor(icp Interceptor) Option[Env] {
+	return WithInterceptorExec[Env](icp)
+}
+
+// WithInterceptorExec is WithInterceptor for helpers that run with an executor of type E.
+func WithInterceptorExec[E Executor](icp Interceptor) Option[E] {
+	return func(o *options[E]) {
+		o.interceptors = append(o.interceptors, icp)
+		o.executor = asExecutor[E]
+	}
+}
+
+// WithMapKeyMaskPaths allows update masks to address individual keys of map fields with string keys, e.g.
+// "labels.foo". By default, maps can only be replaced as a whole. The update statement must then be built with
+// a scrudvalue.Mapping that has MapKeyPaths set.
+func WithMapKeyMaskPaths() Option[Env] {
+	return WithMapKeyMaskPathsExec[Env]()
+}
+
+// WithMapKeyMaskPathsExec is WithMapKeyMaskPaths for helpers that run with an executor of type E.
+func WithMapKeyMaskPathsExec[E any]() Option[E] {
+	return func(o *options[E]) { o.mapKeyMasks = true }
+}
+
+// WithKeyFields sets the fields (and columns) of the items that hold their primary key, defaults to "id".
+// Composite keys have a field for each part, their ids are encoded with CompositeKeyString.
+func WithKeyFields(fields ...string) Option[Env] {
+	return WithKeyFieldsExec[Env](fields...)
+}
+
+// WithKeyFieldsExec is WithKeyFields for helpers that run with an executor of type E.
+func WithKeyFieldsExec[E any](fields ...string) Option[E] {
+	return func(o *options[E]) { o.keyFields = fields }
+}
+
+func applyOptions[E any](opts []Option[E]) (o options[E]) {
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func asExecutor[E Executor](exec E) Executor { return exec }
+
+// keys returns the key fields of the items.
+func (o options[E]) keys() []string {
+	return keyColumnsOrID(o.keyFields)
+}
+
+// pgx returns the transaction and logs of the executor, or nil if no option requires them.
+func (o options[E]) pgx(exec E) (pgx.Tx, *zap.Logger) {
+	if o.executor == nil {
+		return nil, nil
+	}
+
+	ex := o.executor(exec)
+	return ex.PgxTx(), ex.Logger()
+}
+
+// tx returns the transaction of the executor, or nil if no option requires it.
+func (o options[E]) tx(exec E) pgx.Tx {
+	tx, _ := o.pgx(exec)
+	return tx
+}
+
+// intercept runs the mutation through all configured interceptors.
+func (o options[E]) intercept(ctx context.Context, exec E, mut Mutation, next MutationFunc) ([]string, error) {
+	if len(o.interceptors) < 1 {
+		return next(ctx)
+	}
+
+	tx, logs := o.pgx(exec)
+	for i := len(o.interceptors) - 1; i >= 0; i-- {
+		icp, inner := o.interceptors[i], next
+		next = func(ctx context.Context) ([]string, error) {
+			return icp.InterceptMutation(ctx, logs, tx, mut, inner)
+		}
+	}
+
+	return next(ctx)
+}
@@ -0,0 +1,300 @@
+package scrudruntime
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// KeyString formats a primary key as it is returned in ids: strings are returned as-is, integers in decimal
+// and uuids in their canonical form.
+func KeyString(key any) (string, error) {
+	switch key := key.(type) {
+	case string:
+		return key, nil
+	case []byte: // e.g. text columns from database/sql drivers
+		return string(key), nil
+	case int64:
+		return strconv.FormatInt(key, 10), nil
+	case int32:
+		return strconv.FormatInt(int64(key), 10), nil
+	case int:
+		return strconv.Itoa(key), nil
+	case [16]byte:
+		h := hex.EncodeToString(key[:])
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+	default:
+		return "", fmt.Errorf("unsupported primary key: %T", key)
+	}
+}
+
+// CompositeKeyString encodes the parts of a composite key as a single id, so composite keys can be compared
+// by IsOneNotFound and RowsToItemsFunc. Each part is formatted with KeyString.
+func CompositeKeyString(parts ...any) (string, error) {
+	strs := make([]string, 0, len(parts))
+	for i, part := range parts {
+		str, err := KeyString(part)
+		if err != nil {
+			return "", fmt.Errorf("key part %d: %w", i, err)
+		}
+
+		strs = append(strs, url.PathEscape(str))
+	}
+
+	return strings.Join(strs, "/"), nil
+}
+
+// ParseCompositeKey decodes an id that was encoded with CompositeKeyString into its (formatted) parts.
+func ParseCompositeKey(id string, numParts int) ([]string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != numParts {
+		return nil, fmt.Errorf("composite key '%s' must have %d parts, got: %d", id, numParts, len(parts))
+	}
+
+	for i, part := range parts {
+		str, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, fmt.Errorf("key part %d: %w", i, err)
+		}
+
+		parts[i] = str
+	}
+
+	return parts, nil
+}
+
+// Key is the primary key of an item, with a part for each key column in order. The parts keep the kind of the
+// field they were read from (string or int64), so they are passed to SQL as such.
+type Key []any
+
+// ItemKey is how the helpers identify items: by their id, or by their Key.
+type ItemKey interface {
+	string | Key
+}
+
+// KeyOf returns the key of a key message. The fields of the message are the parts of the key, in the order
+// they are declared.
+func KeyOf(key proto.Message) (Key, error) {
+	msg := key.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	parts := make(Key, 0, fields.Len())
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			parts = append(parts, msg.Get(fd).String())
+		case protoreflect.Int64Kind:
+			parts = append(parts, msg.Get(fd).Int())
+		default:
+			return nil, fmt.Errorf("key field '%s' must be a string or int64 field, got: %s", fd.Name(), fd.Kind())
+		}
+	}
+
+	if len(parts) < 1 {
+		return nil, errors.New("key message has no fields")
+	}
+
+	return parts, nil
+}
+
+// KeysOf returns the key of each key message with KeyOf.
+func KeysOf[K proto.Message](keys []K) ([]Key, error) {
+	parts := make([]Key, 0, len(keys))
+	for i, key := range keys {
+		part, err := KeyOf(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
+// ID returns the id of the item, in the same way as ItemKeyString: keys with more than one part are encoded
+// with CompositeKeyString.
+func (k Key) ID() (string, error) {
+	if len(k) == 1 {
+		return KeyString(k[0])
+	}
+
+	return CompositeKeyString(k...)
+}
+
+// KeyMessageString encodes a key message as a single id, it is the ID of its KeyOf.
+func KeyMessageString(key proto.Message) (string, error) {
+	parts, err := KeyOf(key)
+	if err != nil {
+		return "", err
+	}
+
+	return parts.ID()
+}
+
+// KeyMessageStrings encodes each key message with KeyMessageString.
+func KeyMessageStrings[K proto.Message](keys []K) ([]string, error) {
+	ids := make([]string, 0, len(keys))
+	for i, key := range keys {
+		id, err := KeyMessageString(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// ItemKeyString returns the id of an item from its key fields, composite keys (with more than one field) are
+// encoded with CompositeKeyString.
+func ItemKeyString(item proto.Message, fields ...string) (string, error) {
+	msg := item.ProtoReflect()
+	parts := make([]any, 0, len(fields))
+	for _, name := range fields {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return "", fmt.Errorf("message '%s' has no key field '%s'", msg.Descriptor().FullName(), name)
+		}
+
+		parts = append(parts, msg.Get(fd).Interface())
+	}
+
+	switch len(parts) {
+	case 0:
+		return "", errors.New("no key fields")
+	case 1:
+		return KeyString(parts[0])
+	default:
+		return CompositeKeyString(parts...)
+	}
+}
+
+// keyColumnsOrID returns the key columns, or the "id" column if none are given.
+func keyColumnsOrID(cols []string) []string {
+	if len(cols) < 1 {
+		return []string{"id"}
+	}
+
+	return cols
+}
+
+// keyIn returns a condition that matches the rows with the ids or keys, the ids of composite keys are parsed
+// into their parts. The ids must not be empty.
+func keyIn[K ItemKey](cols []string, ids []K) (bob.Expression, error) {
+	keys, err := toKeys(ids, len(cols))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cols) == 1 {
+		args := make([]any, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, key[0])
+		}
+
+		return psql.Quote(cols[0]).In(psql.Arg(args...)), nil
+	}
+
+	groups := make([]bob.Expression, 0, len(keys))
+	for _, key := range keys {
+		groups = append(groups, psql.ArgGroup(key...))
+	}
+
+	return psql.Group(quoteColumns(cols...)...).In(groups...), nil
+}
+
+// toKeys returns the keys of the ids, with a part for each of the key columns. The ids of composite keys are
+// parsed into (string) parts, keys must have the right number of parts already.
+func toKeys[K ItemKey](ids []K, numCols int) ([]Key, error) {
+	keys := make([]Key, 0, len(ids))
+	for _, id := range ids {
+		switch id := any(id).(type) {
+		case Key:
+			if len(id) != numCols {
+				return nil, fmt.Errorf("key must have %d parts, got: %d", numCols, len(id))
+			}
+
+			keys = append(keys, id)
+		case string:
+			if numCols == 1 {
+				keys = append(keys, Key{id})
+				continue
+			}
+
+			parts, err := ParseCompositeKey(id, numCols)
+			if err != nil {
+				return nil, err
+			}
+
+			key := make(Key, 0, len(parts))
+			for _, part := range parts {
+				key = append(key, part)
+			}
+
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// keyIDs returns the id of each id or key, keys are encoded with their ID.
+func keyIDs[K ItemKey](keys []K) ([]string, error) {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		switch key := any(key).(type) {
+		case Key:
+			id, err := key.ID()
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, id)
+		case string:
+			ids = append(ids, key)
+		}
+	}
+
+	return ids, nil
+}
+
+// quoteColumns returns the quoted columns, e.g. for selecting or returning them.
+func quoteColumns(cols ...string) []bob.Expression {
+	exprs := make([]bob.Expression, 0, len(cols))
+	for _, col := range cols {
+		exprs = append(exprs, psql.Quote(col))
+	}
+
+	return exprs
+}
+
+// collectKeys returns the ids of the rows, from their key columns.
+func collectKeys(rows pgx.Rows, cols []string) ([]string, error) {
+	maps, err := pgx.CollectRows(rows, pgx.RowToMap)
+	if err != nil {
+		return nil, fmt.Errorf("collect rows: %w", err)
+	}
+
+	ids := make([]string, 0, len(maps))
+	for _, row := range maps {
+		id, err := getRowID(row, cols)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
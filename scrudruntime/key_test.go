@@ -0,0 +1,75 @@
+package scrudruntime_test
+
+import (
+	"testing"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stephenafamo/bob"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCompositeKeyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	id, err := scrudruntime.CompositeKeyString("org/1", int64(42), "a%b")
+	require.NoError(t, err)
+	require.Equal(t, "org%2F1/42/a%25b", id)
+
+	parts, err := scrudruntime.ParseCompositeKey(id, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"org/1", "42", "a%b"}, parts)
+
+	_, err = scrudruntime.ParseCompositeKey(id, 2)
+	require.ErrorContains(t, err, "must have 2 parts, got: 3")
+}
+
+func TestKeyOf(t *testing.T) {
+	t.Parallel()
+
+	keys, err := scrudruntime.KeysOf([]proto.Message{wrapperspb.String("org/1"), wrapperspb.Int64(42)})
+	require.NoError(t, err)
+	require.Equal(t, []scrudruntime.Key{{"org/1"}, {int64(42)}}, keys)
+
+	id, err := scrudruntime.Key{"org/1", int64(42)}.ID()
+	require.NoError(t, err)
+	require.Equal(t, "org%2F1/42", id)
+
+	id, err = keys[0].ID()
+	require.NoError(t, err)
+	require.Equal(t, "org/1", id)
+
+	_, err = scrudruntime.KeyOf(timestamppb.Now())
+	require.ErrorContains(t, err, "key field 'nanos' must be a string or int64 field, got: int32")
+}
+
+func TestPaginateCompositeKey(t *testing.T) {
+	t.Parallel()
+
+	opts := []scrudruntime.PaginateOption{scrudruntime.WithKeyColumns("org_id", "seq")}
+	query, pagef, err := scrudruntime.PaginateQuery(scrudruntime.DialectPostgres, pageInput{perPage: 1}, "foo", opts...)
+	require.NoError(t, err)
+
+	sql, _, err := bob.Build(t.Context(), query)
+	require.NoError(t, err)
+	require.Contains(t, sql, "ORDER BY created_at, org_id, seq")
+
+	// the rows share their creation time, so the page tie-breaks on both key columns.
+	ids, next, _, err := pagef([]map[string]any{
+		{"org_id": "org/1", "seq": int64(1), "created_at": int64(1)},
+		{"org_id": "org/1", "seq": int64(2), "created_at": int64(1)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"org%2F1/1"}, ids)
+
+	query, _, err = scrudruntime.PaginateQuery(scrudruntime.DialectPostgres,
+		pageInput{perPage: 1, cursor: next}, "foo", opts...)
+	require.NoError(t, err)
+
+	sql, args, err := bob.Build(t.Context(), query)
+	require.NoError(t, err)
+	require.Contains(t, sql, `WHERE ("created_at", "org_id", "seq") > ($1, $2, $3)`)
+	require.Equal(t, []any{int64(1), "org/1", int64(1)}, args)
+}
@@ -0,0 +1,234 @@
+package scrudruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultMaxBatchSize is the maximum number of ids that describe actions accept.
+const DefaultMaxBatchSize = 20
+
+// Loader describes the items of (related) entities in batches, instead of one id at a time. Ids are queued
+// while, for example, the items of a create request are processed. The first load then describes all queued ids
+// of every entity: once per entity with the deduplicated ids, in chunks of the max batch size. Described items
+// are cached for the rest of the request. For example:
+//
+//	ldr := scrudruntime.NewLoader(env, 0)
+//	scrudruntime.RegisterDescriber(ldr, "project", scrudv1.ArchiveFilter_ARCHIVE_FILTER_LIVE, describeProjects)
+//	for _, item := range inp.GetItems() {
+//		ldr.Queue("project", item.GetProjectId())
+//	}
+//	for _, item := range inp.GetItems() {
+//		projects, err := scrudruntime.Load[*projectv1.Project](ctx, ldr, "project", item.GetProjectId())
+//	}
+type Loader[E any] struct {
+	exec         E
+	maxBatchSize int
+
+	mu       sync.Mutex
+	entities map[string]*loaderEntity[E]
+}
+
+type loaderEntity[E any] struct {
+	filter   scrudv1.ArchiveFilter
+	describe func(ctx context.Context, exec E, filter scrudv1.ArchiveFilter, ids []string) ([]proto.Message, error)
+	queued   []string
+	items    map[string]proto.Message
+	missing  map[string]bool
+}
+
+// NewLoader inits a loader that describes with the executor of the request. A max batch size of zero uses the
+// default.
+func NewLoader[E any](exec E, maxBatchSize int) *Loader[E] {
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
+	return &Loader[E]{exec: exec, maxBatchSize: maxBatchSize, entities: map[string]*loaderEntity[E]{}}
+}
+
+// RegisterDescriber registers the function that describes the items of an entity, typically the same function
+// that is passed to DescribePerBatchExec. It must return the items in the order of the ids, and a not found
+// error (see IsOneNotFound) if any of them doesn't exist.
+func RegisterDescriber[E any, OITP proto.Message](
+	ldr *Loader[E],
+	entity string,
+	filter scrudv1.ArchiveFilter,
+	f func(context.Context, E, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+) {
+	ldr.mu.Lock()
+	defer ldr.mu.Unlock()
+
+	ldr.entities[entity] = &loaderEntity[E]{
+		filter: filter,
+		describe: func(ctx context.Context, exec E, filter scrudv1.ArchiveFilter, ids []string) ([]proto.Message, error) {
+			items, err := f(ctx, exec, filter, ids)
+			msgs := make([]proto.Message, 0, len(items))
+			for _, item := range items {
+				msgs = append(msgs, item)
+			}
+
+			return msgs, err
+		},
+		items:   map[string]proto.Message{},
+		missing: map[string]bool{},
+	}
+}
+
+// Queue queues the ids of the entity, they are described by the next load of any entity.
+func (l *Loader[E]) Queue(entity string, ids ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ent, ok := l.entities[entity]
+	if !ok {
+		return fmt.Errorf("no describer registered for entity '%s'", entity)
+	}
+
+	ent.queued = append(ent.queued, ids...)
+	return nil
+}
+
+// Load returns the items of the entity with the ids, in the order of the ids. All queued ids are described
+// first. If any of the ids don't exist a not found error is returned, like IsOneNotFound does.
+func Load[OITP proto.Message, E any](
+	ctx context.Context, ldr *Loader[E], entity string, ids ...string,
+) ([]OITP, error) {
+	if err := ldr.Queue(entity, ids...); err != nil {
+		return nil, err
+	}
+
+	ldr.mu.Lock()
+	defer ldr.mu.Unlock()
+
+	if err := ldr.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	ent := ldr.entities[entity]
+	items, found := make([]OITP, 0, len(ids)), make([]string, 0, len(ids))
+	for _, id := range ids {
+		msg, ok := ent.items[id]
+		if !ok {
+			continue
+		}
+
+		item, ok := msg.(OITP)
+		if !ok {
+			return nil, fmt.Errorf("item of entity '%s' is a %T, expected: %T", entity, msg, item)
+		}
+
+		// callers may modify their items, the cached items must remain as described.
+		items, found = append(items, proto.CloneOf(item)), append(found, id)
+	}
+
+	if err := IsOneNotFound(found, ids); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// flush describes the queued ids of all entities.
+func (l *Loader[E]) flush(ctx context.Context) error {
+	for _, name := range slices.Sorted(maps.Keys(l.entities)) {
+		ent := l.entities[name]
+
+		var todo []string
+		for _, id := range ent.queued {
+			if _, ok := ent.items[id]; ok || ent.missing[id] || slices.Contains(todo, id) {
+				continue
+			}
+
+			todo = append(todo, id)
+		}
+
+		// the ids remain queued until they are described, so a failed load doesn't lose them.
+		for chunk := range slices.Chunk(todo, l.maxBatchSize) {
+			if err := l.describe(ctx, ent, chunk); err != nil {
+				return fmt.Errorf("describe '%s': %w", name, err)
+			}
+		}
+
+		ent.queued = nil
+	}
+
+	return nil
+}
+
+// describe describes one chunk of ids. If any of them doesn't exist, the missing ids are taken from the not
+// found error (see IsOneNotFound) and the others are described again. Describers that don't report the missing
+// ids have their ids described one by one instead.
+func (l *Loader[E]) describe(ctx context.Context, ent *loaderEntity[E], ids []string) error {
+	items, err := ent.describe(ctx, l.exec, ent.filter, ids)
+	switch {
+	case connect.CodeOf(err) == connect.CodeNotFound && len(ids) == 1:
+		ent.missing[ids[0]] = true
+		return nil
+	case connect.CodeOf(err) == connect.CodeNotFound:
+		return l.describeFound(ctx, ent, ids, err)
+	case err != nil:
+		return err
+	case len(items) != len(ids):
+		return fmt.Errorf("described %d items for %d ids", len(items), len(ids))
+	}
+
+	for i, id := range ids {
+		ent.items[id] = items[i]
+	}
+
+	return nil
+}
+
+// describeFound marks the ids of the not found error as missing, and describes the remaining ids.
+func (l *Loader[E]) describeFound(ctx context.Context, ent *loaderEntity[E], ids []string, err error) error {
+	var nfErr *NotFoundError
+	var found []string
+	if errors.As(err, &nfErr) {
+		found = slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return slices.Contains(nfErr.IDs, id) })
+	}
+
+	// without the missing ids, or if they are not part of the chunk, there is nothing to diff.
+	if len(found) == len(ids) {
+		for _, id := range ids {
+			if err := l.describe(ctx, ent, []string{id}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, id := range ids {
+		if !slices.Contains(found, id) {
+			ent.missing[id] = true
+		}
+	}
+
+	if len(found) < 1 {
+		return nil
+	}
+
+	return l.describe(ctx, ent, found)
+}
+
+type loaderKey struct{}
+
+// WithLoader returns a context with the loader of the request, so that custom resolvers can use it.
+func WithLoader[E any](ctx context.Context, ldr *Loader[E]) context.Context {
+	return context.WithValue(ctx, loaderKey{}, ldr)
+}
+
+// LoaderFromContext returns the loader of the request, if any.
+func LoaderFromContext[E any](ctx context.Context) (*Loader[E], bool) {
+	ldr, ok := ctx.Value(loaderKey{}).(*Loader[E])
+	return ldr, ok
+}
@@ -0,0 +1,64 @@
+package scrudruntime_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+
+	var calls [][]string
+	var fail bool
+	ldr := scrudruntime.NewLoader(scrudruntime.Env{}, 2)
+	scrudruntime.RegisterDescriber(ldr, "project", scrudv1.ArchiveFilter_ARCHIVE_FILTER_LIVE,
+		func(_ context.Context, _ scrudruntime.Env, _ scrudv1.ArchiveFilter, ids []string) (
+			[]*wrapperspb.StringValue, error,
+		) {
+			calls = append(calls, ids)
+			if fail {
+				fail = false
+				return nil, errors.New("connection lost")
+			}
+
+			items, found := make([]*wrapperspb.StringValue, 0, len(ids)), make([]string, 0, len(ids))
+			for _, id := range ids {
+				if id != "prj_x" {
+					items, found = append(items, wrapperspb.String(id)), append(found, id)
+				}
+			}
+
+			return items, scrudruntime.IsOneNotFound(found, ids)
+		})
+
+	require.NoError(t, ldr.Queue("project", "prj_1", "prj_2", "prj_1", "prj_3"))
+	items, err := scrudruntime.Load[*wrapperspb.StringValue](t.Context(), ldr, "project", "prj_3", "prj_1")
+	require.NoError(t, err)
+	require.Equal(t, "prj_3", items[0].GetValue())
+	require.Equal(t, "prj_1", items[1].GetValue())
+	require.Equal(t, [][]string{{"prj_1", "prj_2"}, {"prj_3"}}, calls)
+
+	// cached items are not described again, missing ids are reported as not found and the others of the same
+	// chunk are described again without them.
+	_, err = scrudruntime.Load[*wrapperspb.StringValue](t.Context(), ldr, "project", "prj_2", "prj_x", "prj_4")
+	require.EqualError(t, err, "not_found: could not find id(s): prj_x")
+	require.Equal(t, [][]string{{"prj_1", "prj_2"}, {"prj_3"}, {"prj_x", "prj_4"}, {"prj_4"}}, calls)
+
+	// queued ids are kept when describing fails, so the next load describes them.
+	calls, fail = nil, true
+	require.NoError(t, ldr.Queue("project", "prj_5"))
+	_, err = scrudruntime.Load[*wrapperspb.StringValue](t.Context(), ldr, "project", "prj_6")
+	require.ErrorContains(t, err, "connection lost")
+	items, err = scrudruntime.Load[*wrapperspb.StringValue](t.Context(), ldr, "project", "prj_6")
+	require.NoError(t, err)
+	require.Equal(t, "prj_6", items[0].GetValue())
+	require.Equal(t, [][]string{{"prj_5", "prj_6"}, {"prj_5", "prj_6"}}, calls)
+
+	require.ErrorContains(t, ldr.Queue("bogus", "x"), "no describer registered for entity 'bogus'")
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 
 	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudvalue"
 	"github.com/jackc/pgx/v5"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
@@ -37,20 +39,320 @@ func CreatePerItem[
 	},
 ](
 	f func(context.Context, *zap.Logger, pgx.Tx, IITP) (string, error),
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
-		var err error
-		ids := make([]string, 0, len(inp.GetItems()))
-		for _, item := range inp.GetItems() {
-			id, ferr := f(ctx, logs, tx, item)
-			err = errors.Join(err, ferr)
-			ids = append(ids, id)
+	return ToPgx(CreatePerItemExec[I, O, IP, OP, IIT, IITP, Env](
+		func(ctx context.Context, env Env, item IITP) (string, error) {
+			return f(ctx, env.Logs, env.Tx, item)
+		},
+		opts...,
+	))
+}
+
+// CreatePerItemExec is CreatePerItem for actions that run with an executor of type E, e.g. Env.
+func CreatePerItemExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetIds(ids []string)
+	},
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, IITP) (string, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return createPerItem[I, O, IP, OP](
+		"scrudruntime.CreatePerItem", f, idOfID, func(op OP, ids []string) { op.SetIds(ids) }, opts)
+}
+
+// createPerItem is the implementation that is shared by the create helpers. The items are identified by a key
+// of type K, an id or a key message, that idOf encodes as the id that the interceptors receive.
+func createPerItem[
+	I any,
+	O any,
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	OP interface {
+		*O
+		proto.Message
+	},
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	E any,
+	K any,
+](
+	span string,
+	f func(context.Context, E, IITP) (K, error),
+	idOf func(K) (string, error),
+	setKeys func(OP, []K),
+	opts []Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, span)
+		defer end()
+
+		return idempotent[O, OP](ctx, opt.tx(exec), opt.idempotency, inp, func() (OP, error) {
+			var keys []K
+			mut := Mutation{Action: scrudv1.ActionKind_ACTION_KIND_CREATE}
+			_, err := opt.intercept(ctx, exec, mut, func(ctx context.Context) (ids []string, err error) {
+				keys = make([]K, 0, len(inp.GetItems()))
+				ids = make([]string, 0, len(inp.GetItems()))
+				for _, item := range inp.GetItems() {
+					var id string
+					key, ferr := f(ctx, exec, item)
+					if ferr == nil {
+						id, ferr = idOf(key)
+					}
+
+					err = errors.Join(err, ferr)
+					keys, ids = append(keys, key), append(ids, id)
+				}
+
+				return ids, err
+			})
+
+			var op OP = new(O)
+			setKeys(op, keys)
+			return op, err
+		})
+	}
+}
+
+// UpsertPerBatch creates the items that don't exist yet, and updates the items that do. The function must
+// return the id of every item, and whether it was created, in the order of the items. For example by executing
+// the statement from scrudvalue.UpsertMods.
+func UpsertPerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetIds(ids []string)
+		SetCreated(created []bool)
+	},
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, []IITP) ([]string, []bool, error),
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(UpsertPerBatchExec[I, O, IP, OP, IIT, IITP, Env](
+		func(ctx context.Context, env Env, items []IITP) ([]string, []bool, error) {
+			return f(ctx, env.Logs, env.Tx, items)
+		},
+		opts...,
+	))
+}
+
+// UpsertPerBatchExec is UpsertPerBatch for actions that run with an executor of type E, e.g. Env.
+func UpsertPerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetIds(ids []string)
+		SetCreated(created []bool)
+	},
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []IITP) ([]string, []bool, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return upsertPerBatch[I, O, IP, OP](
+		"scrudruntime.UpsertPerBatch", f, idOfID, func(op OP, ids []string, created []bool) {
+			op.SetIds(ids)
+			op.SetCreated(created)
+		}, opts)
+}
+
+// upsertPerBatch is the implementation that is shared by the upsert helpers, the items are identified by keys
+// like in createPerItem.
+func upsertPerBatch[
+	I any,
+	O any,
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	OP interface {
+		*O
+		proto.Message
+	},
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	E any,
+	K any,
+](
+	span string,
+	f func(context.Context, E, []IITP) ([]K, []bool, error),
+	idOf func(K) (string, error),
+	setKeys func(OP, []K, []bool),
+	opts []Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, span)
+		defer end()
+
+		var keys []K
+		var created []bool
+		mut := Mutation{Action: scrudv1.ActionKind_ACTION_KIND_UPSERT}
+		if _, err := opt.intercept(ctx, exec, mut, func(ctx context.Context) (ids []string, err error) {
+			if keys, created, err = f(ctx, exec, inp.GetItems()); err != nil {
+				return nil, err
+			}
+
+			if len(keys) != len(inp.GetItems()) || len(created) != len(keys) {
+				return nil, fmt.Errorf("upserted %d items, got %d keys and %d created flags",
+					len(inp.GetItems()), len(keys), len(created))
+			}
+
+			ids = make([]string, 0, len(keys))
+			for _, key := range keys {
+				id, err := idOf(key)
+				if err != nil {
+					return nil, err
+				}
+
+				ids = append(ids, id)
+			}
+
+			return ids, nil
+		}); err != nil {
+			return nil, err
 		}
 
 		var op OP = new(O)
-		op.SetIds(ids)
-		return op, err
+		setKeys(op, keys, created)
+		return op, nil
+	}
+}
+
+// idOfID is the idOf function of helpers that identify items by their id.
+func idOfID(id string) (string, error) { return id, nil }
+
+// mutatePerBatch executes the mutation of the items with the given ids (or keys) in a single call to f, it is
+// shared by the helpers that take them from an input with "ids" or "keys".
+func mutatePerBatch[
+	O any,
+	OP interface {
+		*O
+		proto.Message
+	},
+	E any,
+	K ItemKey,
+](
+	ctx context.Context,
+	exec E,
+	opt options[E],
+	action scrudv1.ActionKind,
+	inp proto.Message,
+	keys []K,
+	f func(context.Context, E, []K) error,
+) (OP, error) {
+	ids, err := keyIDs(keys)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	mut := Mutation{Action: action, IDs: ids}
+	if _, err := opt.intercept(ctx, exec, mut, func(ctx context.Context) ([]string, error) {
+		if err := checkVersions(ctx, opt, exec, keys, inputVersions(inp)); err != nil {
+			return nil, err
+		}
+
+		return mut.IDs, f(ctx, exec, keys)
+	}); err != nil {
+		return nil, err
 	}
+
+	var op OP = new(O)
+	return op, nil
+}
+
+// envBatchFunc adapts a batch function of the legacy helpers to the Env executor.
+func envBatchFunc[K ItemKey](
+	f func(context.Context, *zap.Logger, pgx.Tx, []K) error,
+) func(context.Context, Env, []K) error {
+	return func(ctx context.Context, env Env, keys []K) error {
+		return f(ctx, env.Logs, env.Tx, keys)
+	}
+}
+
+// itemVersions returns the versions of the items, or nil if they don't carry them.
+func itemVersions[IITP any](items []IITP) (versions []int64) {
+	for _, item := range items {
+		versioned, ok := any(item).(interface{ GetVersion() int64 })
+		if !ok {
+			return nil
+		}
+
+		versions = append(versions, versioned.GetVersion())
+	}
+
+	return versions
+}
+
+// inputVersions returns the versions of the ids in the input, or nil if it doesn't carry them.
+func inputVersions(inp any) []int64 {
+	if versioned, ok := inp.(interface{ GetVersions() []int64 }); ok {
+		return versioned.GetVersions()
+	}
+
+	return nil
 }
 
 func appendErr(id string, err, opErr error) error {
@@ -87,27 +389,96 @@ func ModifyPerItem[
 	IITP interface {
 		*IIT
 		proto.Message
-		GetId() string
 		GetMask() *fieldmaskpb.FieldMask
 	},
 ](
 	f func(context.Context, *zap.Logger, pgx.Tx, IITP) error,
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
+	return ToPgx(ModifyPerItemExec[I, O, IP, OP, IIT, IITP, Env](
+		func(ctx context.Context, env Env, item IITP) error {
+			return f(ctx, env.Logs, env.Tx, item)
+		},
+		opts...,
+	))
+}
+
+// ModifyPerItemExec is ModifyPerItem for actions that run with an executor of type E, e.g. Env.
+func ModifyPerItemExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+		GetMask() *fieldmaskpb.FieldMask
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, IITP) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.ModifyPerItem")
+		defer end()
+
 		var err error
+		var todo []IITP
+		mut := Mutation{Action: scrudv1.ActionKind_ACTION_KIND_MODIFY, Masks: map[string]*fieldmaskpb.FieldMask{}}
 		for _, item := range inp.GetItems() {
 			// if the update mask is empty, it means nothing will be updated so we skip the implementation altogether.
 			if len(item.GetMask().GetPaths()) < 1 {
 				continue
 			}
 
+			id, kErr := ItemKeyString(item, opt.keys()...)
+			if kErr != nil {
+				return nil, fmt.Errorf("item key: %w", kErr)
+			}
+
 			// report invalid mask.
-			if !item.GetMask().IsValid(item) {
-				err = appendErr(item.GetId(), err, fmt.Errorf("invalid update mask: %v", item.GetMask().GetPaths()))
-			} else {
-				opErr := f(ctx, logs, tx, item)
-				err = appendErr(item.GetId(), err, opErr)
+			if vErr := scrudvalue.ValidateMask(item, item.GetMask(), opt.mapKeyMasks); vErr != nil {
+				err = appendErr(id, err, fmt.Errorf("invalid update mask: %w", vErr))
+				continue
 			}
+
+			// sort the paths, and remove the paths that are covered by others. The mask is cloned so the
+			// caller's item is not modified.
+			mask := proto.CloneOf(item.GetMask())
+			mask.Normalize()
+
+			todo = append(todo, item)
+			mut.IDs = append(mut.IDs, id)
+			mut.Masks[id] = mask
+		}
+
+		if len(todo) > 0 {
+			_, opErr := opt.intercept(ctx, exec, mut, func(ctx context.Context) (ids []string, err error) {
+				if err := checkVersions(ctx, opt, exec, mut.IDs, itemVersions(todo)); err != nil {
+					return nil, err
+				}
+
+				for idx, item := range todo {
+					err = appendErr(mut.IDs[idx], err, f(ctx, exec, item))
+				}
+
+				return mut.IDs, err
+			})
+			err = errors.Join(err, opErr)
 		}
 
 		var op OP = new(O)
@@ -131,27 +502,142 @@ func RemovePerBatch[
 	},
 ](
 	f func(context.Context, *zap.Logger, pgx.Tx, []string) error,
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
-		if err := f(ctx, logs, tx, inp.GetIds()); err != nil {
+	return ToPgx(RemovePerBatchExec[I, O, IP, OP, Env](envBatchFunc(f), opts...))
+}
+
+// RemovePerBatchExec is RemovePerBatch for actions that run with an executor of type E, e.g. Env.
+func RemovePerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIds() []string
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []string) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.RemovePerBatch")
+		defer end()
+
+		return mutatePerBatch[O, OP](ctx, exec, opt, scrudv1.ActionKind_ACTION_KIND_REMOVE, inp, inp.GetIds(), f)
+	}
+}
+
+func DescribePerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIds() []string
+		GetConsiderArchived() bool
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(DescribePerBatchExec[I, O, IP, OP, OIT, OITP, Env](
+		func(ctx context.Context, env Env, filter scrudv1.ArchiveFilter, ids []string) ([]OITP, error) {
+			return f(ctx, env.Logs, env.Tx, filter, ids)
+		},
+		opts...,
+	))
+}
+
+// DescribePerBatchExec is DescribePerBatch for actions that run with an executor of type E, e.g. Env.
+func DescribePerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIds() []string
+		GetConsiderArchived() bool
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.DescribePerBatch")
+		defer end()
+
+		ctx, mask, err := withReadMask[OIT, OITP](ctx, inp)
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := f(ctx, exec, ArchiveFilterOf(inp), inp.GetIds())
+		if err != nil {
 			return nil, err
 		}
 
+		pruneItems(items, mask, opt.keys())
+
 		var op OP = new(O)
-		return op, nil
+		op.SetItems(items)
+		return op, err
 	}
 }
 
-func DescribePerBatch[
+// DescribeByKeysPerBatch is DescribePerBatch for entities with a composite key, their inputs have a repeated
+// "keys" field instead of "ids". The keys are passed to f encoded with KeyMessageString, in the same way
+// ListAndDescribePerBatch passes the ids of composite keys.
+func DescribeByKeysPerBatch[
 	I any,
 	O any,
 	// input
 	IP interface {
 		*I
 		proto.Message
-		GetIds() []string
+		GetKeys() []KP
 		GetConsiderArchived() bool
 	},
+	// key message
+	KP proto.Message,
 	// output
 	OP interface {
 		*O
@@ -165,14 +651,70 @@ func DescribePerBatch[
 		proto.Message
 	},
 ](
-	f func(context.Context, *zap.Logger, pgx.Tx, bool, []string) ([]OITP, error),
+	f func(context.Context, *zap.Logger, pgx.Tx, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
-		items, err := f(ctx, logs, tx, inp.GetConsiderArchived(), inp.GetIds())
+	return ToPgx(DescribeByKeysPerBatchExec[I, O, IP, KP, OP, OIT, OITP, Env](
+		func(ctx context.Context, env Env, filter scrudv1.ArchiveFilter, ids []string) ([]OITP, error) {
+			return f(ctx, env.Logs, env.Tx, filter, ids)
+		},
+		opts...,
+	))
+}
+
+// DescribeByKeysPerBatchExec is DescribeByKeysPerBatch for actions that run with an executor of type E, e.g. Env.
+func DescribeByKeysPerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+		GetConsiderArchived() bool
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.DescribeByKeysPerBatch")
+		defer end()
+
+		ctx, mask, err := withReadMask[OIT, OITP](ctx, inp)
+		if err != nil {
+			return nil, err
+		}
+
+		ids, err := KeyMessageStrings(inp.GetKeys())
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+
+		items, err := f(ctx, exec, ArchiveFilterOf(inp), ids)
 		if err != nil {
 			return nil, err
 		}
 
+		pruneItems(items, mask, opt.keys())
+
 		var op OP = new(O)
 		op.SetItems(items)
 		return op, err
@@ -186,7 +728,6 @@ func ListAndDescribePerBatch[
 	IP interface {
 		*I
 		proto.Message
-		GetShowArchived() bool
 	},
 	// output
 	OP interface {
@@ -204,27 +745,250 @@ func ListAndDescribePerBatch[
 	},
 ](
 	listf func(context.Context, *zap.Logger, pgx.Tx, IP) ([]string, []byte, []byte, error),
-	descf func(context.Context, *zap.Logger, pgx.Tx, bool, []string) ([]OITP, error),
+	descf func(context.Context, *zap.Logger, pgx.Tx, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, i IP) (OP, error) {
-		ids, nextCursor, previousCursor, err := listf(ctx, logs, tx, i)
+	return ToPgx(ListAndDescribePerBatchExec[I, O, IP, OP, OIT, OITP, Env](
+		func(ctx context.Context, env Env, inp IP) ([]string, []byte, []byte, error) {
+			return listf(ctx, env.Logs, env.Tx, inp)
+		},
+		func(ctx context.Context, env Env, filter scrudv1.ArchiveFilter, ids []string) ([]OITP, error) {
+			return descf(ctx, env.Logs, env.Tx, filter, ids)
+		},
+		opts...,
+	))
+}
+
+// ListAndDescribePerBatchExec is ListAndDescribePerBatch for actions that run with an executor of type E, e.g. Env.
+func ListAndDescribePerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+		SetNextCursor(cursor []byte)
+		SetPreviousCursor(cursor []byte)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	listf func(context.Context, E, IP) ([]string, []byte, []byte, error),
+	descf func(context.Context, E, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, i IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.ListAndDescribePerBatch")
+		defer end()
+
+		ctx, mask, err := withReadMask[OIT, OITP](ctx, i)
 		if err != nil {
 			return nil, err
 		}
 
-		items, err := descf(ctx, logs, tx, i.GetShowArchived(), ids)
+		ids, nextCursor, previousCursor, err := listf(ctx, exec, i)
 		if err != nil {
 			return nil, err
 		}
 
+		items, err := descf(ctx, exec, ArchiveFilterOf(i), ids)
+		if err != nil {
+			return nil, err
+		}
+
+		pruneItems(items, mask, opt.keys())
+
 		var op OP = new(O)
 		op.SetItems(items)
 		op.SetNextCursor(nextCursor)
 		op.SetPreviousCursor(previousCursor)
+
+		// outputs may support jumping to the first and last page.
+		if edges, ok := any(op).(interface {
+			SetFirstCursor(cursor []byte)
+			SetLastCursor(cursor []byte)
+		}); ok {
+			first, last, err := EdgeCursors()
+			if err != nil {
+				return nil, err
+			}
+
+			edges.SetFirstCursor(first)
+			edges.SetLastCursor(last)
+		}
+
 		return op, err
 	}
 }
 
+// ListCountAndDescribePerBatch is ListAndDescribePerBatch for inputs that can request the total number of rows.
+// The countf function is only called if the input includes the total, e.g. by calling CountTotal.
+func ListCountAndDescribePerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIncludeTotal() bool
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+		SetNextCursor(cursor []byte)
+		SetPreviousCursor(cursor []byte)
+		SetTotalCount(count int64)
+		SetTotalIsEstimate(estimate bool)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+](
+	listf func(context.Context, *zap.Logger, pgx.Tx, IP) ([]string, []byte, []byte, error),
+	countf func(context.Context, *zap.Logger, pgx.Tx, IP) (int64, bool, error),
+	descf func(context.Context, *zap.Logger, pgx.Tx, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(ListCountAndDescribePerBatchExec[I, O, IP, OP, OIT, OITP, Env](
+		func(ctx context.Context, env Env, inp IP) ([]string, []byte, []byte, error) {
+			return listf(ctx, env.Logs, env.Tx, inp)
+		},
+		func(ctx context.Context, env Env, inp IP) (int64, bool, error) {
+			return countf(ctx, env.Logs, env.Tx, inp)
+		},
+		func(ctx context.Context, env Env, filter scrudv1.ArchiveFilter, ids []string) ([]OITP, error) {
+			return descf(ctx, env.Logs, env.Tx, filter, ids)
+		},
+		opts...,
+	))
+}
+
+// ListCountAndDescribePerBatchExec is ListCountAndDescribePerBatch for actions that run with an executor of
+// type E, e.g. Env.
+func ListCountAndDescribePerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIncludeTotal() bool
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(items []OITP)
+		SetNextCursor(cursor []byte)
+		SetPreviousCursor(cursor []byte)
+		SetTotalCount(count int64)
+		SetTotalIsEstimate(estimate bool)
+	},
+	// output item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	listf func(context.Context, E, IP) ([]string, []byte, []byte, error),
+	countf func(context.Context, E, IP) (int64, bool, error),
+	descf func(context.Context, E, scrudv1.ArchiveFilter, []string) ([]OITP, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	list := ListAndDescribePerBatchExec[I, O, IP, OP, OIT, OITP, E](listf, descf, opts...)
+	return func(ctx context.Context, exec E, i IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.ListCountAndDescribePerBatch")
+		defer end()
+
+		op, err := list(ctx, exec, i)
+		if err != nil || !i.GetIncludeTotal() {
+			return op, err
+		}
+
+		total, estimate, err := countf(ctx, exec, i)
+		if err != nil {
+			return nil, fmt.Errorf("count total: %w", err)
+		}
+
+		op.SetTotalCount(total)
+		op.SetTotalIsEstimate(estimate)
+		return op, nil
+	}
+}
+
+// PurgePerBatch permanently deletes archived items, the function can use PurgeArchived to do so.
+func PurgePerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIds() []string
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, []string) error,
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(PurgePerBatchExec[I, O, IP, OP, Env](envBatchFunc(f), opts...))
+}
+
+// PurgePerBatchExec is PurgePerBatch for actions that run with an executor of type E, e.g. Env.
+func PurgePerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIds() []string
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []string) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.PurgePerBatch")
+		defer end()
+
+		return mutatePerBatch[O, OP](ctx, exec, opt, scrudv1.ActionKind_ACTION_KIND_PURGE, inp, inp.GetIds(), f)
+	}
+}
+
 func RestorePerBatch[
 	I any,
 	O any,
@@ -241,13 +1005,37 @@ func RestorePerBatch[
 	},
 ](
 	f func(context.Context, *zap.Logger, pgx.Tx, []string) error,
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
-		if err := f(ctx, logs, tx, inp.GetIds()); err != nil {
-			return nil, err
-		}
+	return ToPgx(RestorePerBatchExec[I, O, IP, OP, Env](envBatchFunc(f), opts...))
+}
 
-		var op OP = new(O)
-		return op, nil
+// RestorePerBatchExec is RestorePerBatch for actions that run with an executor of type E, e.g. Env.
+func RestorePerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetIds() []string
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []string) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.RestorePerBatch")
+		defer end()
+
+		return mutatePerBatch[O, OP](ctx, exec, opt, scrudv1.ActionKind_ACTION_KIND_RESTORE, inp, inp.GetIds(), f)
 	}
 }
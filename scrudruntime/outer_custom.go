@@ -38,19 +38,68 @@ func CustomItemsToItemsPerItem[
 	},
 ](
 	f func(context.Context, *zap.Logger, pgx.Tx, int, IITP) (OITP, error),
+	opts ...Option[Env],
 ) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
-	return func(ctx context.Context, logs *zap.Logger, tx pgx.Tx, inp IP) (OP, error) {
-		var err error
-		items := make([]OITP, 0, len(inp.GetItems()))
-		for idx, inItem := range inp.GetItems() {
-			outItem, ferr := f(ctx, logs, tx, idx, inItem)
-			err = errors.Join(err, ferr)
-			items = append(items, outItem)
-		}
+	return ToPgx(CustomItemsToItemsPerItemExec[I, O, IP, OP, OIT, OITP, IIT, IITP, Env](
+		func(ctx context.Context, env Env, idx int, item IITP) (OITP, error) {
+			return f(ctx, env.Logs, env.Tx, idx, item)
+		},
+		opts...,
+	))
+}
+
+// CustomItemsToItemsPerItemExec is CustomItemsToItemsPerItem for actions that run with an executor of type E, e.g. Env.
+func CustomItemsToItemsPerItemExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetItems(v []OITP)
+	},
+	// input item
+	OIT any,
+	OITP interface {
+		*OIT
+		proto.Message
+	},
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, int, IITP) (OITP, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, "scrudruntime.CustomItemsToItemsPerItem")
+		defer end()
+
+		return idempotent[O, OP](ctx, opt.tx(exec), opt.idempotency, inp, func() (OP, error) {
+			var err error
+			items := make([]OITP, 0, len(inp.GetItems()))
+			for idx, inItem := range inp.GetItems() {
+				outItem, ferr := f(ctx, exec, idx, inItem)
+				err = errors.Join(err, ferr)
+				items = append(items, outItem)
+			}
 
-		var op OP = new(O)
-		op.SetItems(items)
-		return op, err
+			var op OP = new(O)
+			op.SetItems(items)
+			return op, err
+		})
 	}
 }
 
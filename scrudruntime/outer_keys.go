@@ -0,0 +1,352 @@
+package scrudruntime
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// CreateByKeysPerItem is CreatePerItem for entities with a composite key, their outputs have a repeated "keys"
+// field instead of "ids". The function returns the key of each created item, interceptors receive the keys
+// encoded with KeyMessageString.
+func CreateByKeysPerItem[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetKeys(keys []KP)
+	},
+	// key message
+	KP proto.Message,
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, IITP) (KP, error),
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(CreateByKeysPerItemExec[I, O, IP, OP, KP, IIT, IITP, Env](
+		func(ctx context.Context, env Env, item IITP) (KP, error) {
+			return f(ctx, env.Logs, env.Tx, item)
+		},
+		opts...,
+	))
+}
+
+// CreateByKeysPerItemExec is CreateByKeysPerItem for actions that run with an executor of type E, e.g. Env.
+func CreateByKeysPerItemExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetKeys(keys []KP)
+	},
+	// key message
+	KP proto.Message,
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, IITP) (KP, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return createPerItem[I, O, IP, OP](
+		"scrudruntime.CreateByKeysPerItem", f, keyMessageID[KP], func(op OP, keys []KP) { op.SetKeys(keys) }, opts)
+}
+
+// UpsertByKeysPerBatch is UpsertPerBatch for entities with a composite key, their outputs have a repeated "keys"
+// field instead of "ids". The function must return the key of every item, and whether it was created, in the
+// order of the items.
+func UpsertByKeysPerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetKeys(keys []KP)
+		SetCreated(created []bool)
+	},
+	// key message
+	KP proto.Message,
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, []IITP) ([]KP, []bool, error),
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(UpsertByKeysPerBatchExec[I, O, IP, OP, KP, IIT, IITP, Env](
+		func(ctx context.Context, env Env, items []IITP) ([]KP, []bool, error) {
+			return f(ctx, env.Logs, env.Tx, items)
+		},
+		opts...,
+	))
+}
+
+// UpsertByKeysPerBatchExec is UpsertByKeysPerBatch for actions that run with an executor of type E, e.g. Env.
+func UpsertByKeysPerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetItems() []IITP
+	},
+	// output
+	OP interface {
+		*O
+		proto.Message
+		SetKeys(keys []KP)
+		SetCreated(created []bool)
+	},
+	// key message
+	KP proto.Message,
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []IITP) ([]KP, []bool, error),
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return upsertPerBatch[I, O, IP, OP](
+		"scrudruntime.UpsertByKeysPerBatch", f, keyMessageID[KP], func(op OP, keys []KP, created []bool) {
+			op.SetKeys(keys)
+			op.SetCreated(created)
+		}, opts)
+}
+
+// RemoveByKeysPerBatch is RemovePerBatch for entities with a composite key, their inputs have a repeated "keys"
+// field instead of "ids". The keys are passed to f with KeyOf, so each part keeps the kind of its field.
+func RemoveByKeysPerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, []Key) error,
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(RemoveByKeysPerBatchExec[I, O, IP, KP, OP, Env](envBatchFunc(f), opts...))
+}
+
+// RemoveByKeysPerBatchExec is RemoveByKeysPerBatch for actions that run with an executor of type E, e.g. Env.
+func RemoveByKeysPerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []Key) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return mutateByKeysPerBatch[I, O, IP, KP, OP](
+		"scrudruntime.RemoveByKeysPerBatch", scrudv1.ActionKind_ACTION_KIND_REMOVE, f, opts)
+}
+
+// RestoreByKeysPerBatch is RestorePerBatch for entities with a composite key, their inputs have a repeated
+// "keys" field instead of "ids". The keys are passed to f with KeyOf, like RemoveByKeysPerBatch.
+func RestoreByKeysPerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, []Key) error,
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(RestoreByKeysPerBatchExec[I, O, IP, KP, OP, Env](envBatchFunc(f), opts...))
+}
+
+// RestoreByKeysPerBatchExec is RestoreByKeysPerBatch for actions that run with an executor of type E, e.g. Env.
+func RestoreByKeysPerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []Key) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return mutateByKeysPerBatch[I, O, IP, KP, OP](
+		"scrudruntime.RestoreByKeysPerBatch", scrudv1.ActionKind_ACTION_KIND_RESTORE, f, opts)
+}
+
+// PurgeByKeysPerBatch is PurgePerBatch for entities with a composite key, their inputs have a repeated "keys"
+// field instead of "ids". The keys are passed to f with KeyOf, which PurgeArchived accepts when it is given the
+// key columns.
+func PurgeByKeysPerBatch[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+](
+	f func(context.Context, *zap.Logger, pgx.Tx, []Key) error,
+	opts ...Option[Env],
+) func(context.Context, *zap.Logger, pgx.Tx, IP) (OP, error) {
+	return ToPgx(PurgeByKeysPerBatchExec[I, O, IP, KP, OP, Env](envBatchFunc(f), opts...))
+}
+
+// PurgeByKeysPerBatchExec is PurgeByKeysPerBatch for actions that run with an executor of type E, e.g. Env.
+func PurgeByKeysPerBatchExec[
+	I any,
+	O any,
+	// input
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	// key message
+	KP proto.Message,
+	// output
+	OP interface {
+		*O
+		proto.Message
+	},
+	// executor, e.g. Env
+	E any,
+](
+	f func(context.Context, E, []Key) error,
+	opts ...Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	return mutateByKeysPerBatch[I, O, IP, KP, OP](
+		"scrudruntime.PurgeByKeysPerBatch", scrudv1.ActionKind_ACTION_KIND_PURGE, f, opts)
+}
+
+// mutateByKeysPerBatch returns the implementation that is shared by the batch mutations of composite keys.
+func mutateByKeysPerBatch[
+	I any,
+	O any,
+	IP interface {
+		*I
+		proto.Message
+		GetKeys() []KP
+	},
+	KP proto.Message,
+	OP interface {
+		*O
+		proto.Message
+	},
+	E any,
+](
+	span string, action scrudv1.ActionKind, f func(context.Context, E, []Key) error, opts []Option[E],
+) func(context.Context, E, IP) (OP, error) {
+	opt := applyOptions(opts)
+	return func(ctx context.Context, exec E, inp IP) (OP, error) {
+		ctx, end := startSpan(ctx, exec, span)
+		defer end()
+
+		keys, err := KeysOf(inp.GetKeys())
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+
+		return mutatePerBatch[O, OP](ctx, exec, opt, action, inp, keys, f)
+	}
+}
+
+// keyMessageID is the idOf function of helpers that identify items by a key message.
+func keyMessageID[KP proto.Message](key KP) (string, error) { return KeyMessageString(key) }
@@ -0,0 +1,93 @@
+package scrudruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dm"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+)
+
+// PurgeArchived permanently deletes the rows with the given ids (or keys) from the base table. Only rows that
+// have been archived can be purged, if any of the rows is still live nothing is deleted and a failed
+// precondition error is returned. Rows that don't exist are reported as not found. The key columns of the table
+// default to "id", composite keys have a column for each part.
+func PurgeArchived[K ItemKey](
+	ctx context.Context, tx pgx.Tx, baseTableName string, ids []K, keyCols ...string,
+) error {
+	if len(ids) < 1 {
+		return nil
+	}
+
+	keyCols = keyColumnsOrID(keyCols)
+	match, err := keyIn(keyCols, ids)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	expected, err := keyIDs(ids)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	cols := []any{psql.Raw("archived_at IS NOT NULL AS scrud_archived")}
+	for _, col := range quoteColumns(keyCols...) {
+		cols = append(cols, col)
+	}
+
+	// lock the rows, so they cannot be restored between the check and the deletion.
+	sql, args, err := psql.Select(
+		sm.Columns(cols...), sm.From(psql.Quote(baseTableName)), sm.Where(match), sm.ForUpdate(),
+	).Build(ctx)
+	if err != nil {
+		return fmt.Errorf("build query: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("query rows to purge: %w", err)
+	}
+
+	maps, err := pgx.CollectRows(rows, pgx.RowToMap)
+	if err != nil {
+		return fmt.Errorf("scan rows to purge: %w", err)
+	}
+
+	var existing, live []string
+	for _, row := range maps {
+		id, err := getRowID(row, keyCols)
+		if err != nil {
+			return err
+		}
+
+		existing = append(existing, id)
+		if archived, _ := row["scrud_archived"].(bool); !archived {
+			live = append(live, id)
+		}
+	}
+
+	if err := IsOneNotFound(existing, expected); err != nil {
+		return err
+	}
+
+	if len(live) > 0 {
+		return connect.NewError(connect.CodeFailedPrecondition,
+			errors.New("only archived items can be purged, still live: "+strings.Join(live, ",")))
+	}
+
+	sql, args, err = psql.Delete(dm.From(psql.Quote(baseTableName)), dm.Where(match)).Build(ctx)
+	if err != nil {
+		return fmt.Errorf("build delete: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("delete purged rows: %w", err)
+	}
+
+	return nil
+}
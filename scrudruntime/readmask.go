@@ -0,0 +1,71 @@
+package scrudruntime
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/advdv/scrud/scrudvalue"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type readMaskKey struct{}
+
+// WithReadMask returns a context that carries the read mask of the request.
+func WithReadMask(ctx context.Context, mask *fieldmaskpb.FieldMask) context.Context {
+	return context.WithValue(ctx, readMaskKey{}, mask)
+}
+
+// ReadMaskFromContext returns the read mask of the request, or nil if all fields are to be returned. Describe
+// implementations can use it to select fewer columns.
+func ReadMaskFromContext(ctx context.Context) *fieldmaskpb.FieldMask {
+	mask, _ := ctx.Value(readMaskKey{}).(*fieldmaskpb.FieldMask)
+	return mask
+}
+
+// readMask returns the (optional) "read_mask" field of the input. It returns nil if the input has no such field,
+// or if the mask is empty.
+func readMask(inp proto.Message) *fieldmaskpb.FieldMask {
+	msg := inp.ProtoReflect()
+	fd := msg.Descriptor().Fields().ByName("read_mask")
+	if fd == nil || fd.Message() == nil || fd.Message().FullName() != "google.protobuf.FieldMask" || !msg.Has(fd) {
+		return nil
+	}
+
+	mask, ok := msg.Get(fd).Message().Interface().(*fieldmaskpb.FieldMask)
+	if !ok || len(mask.GetPaths()) < 1 {
+		return nil
+	}
+
+	return mask
+}
+
+// withReadMask validates the read mask of the input against the item type, and adds it to the context.
+func withReadMask[OIT any, OITP interface {
+	*OIT
+	proto.Message
+}](ctx context.Context, inp proto.Message) (context.Context, *fieldmaskpb.FieldMask, error) {
+	mask := readMask(inp)
+	if mask == nil {
+		return ctx, nil, nil
+	}
+
+	var item OITP = new(OIT)
+	if err := scrudvalue.ValidateMask(item, mask, false); err != nil {
+		return ctx, nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid read mask: %w", err))
+	}
+
+	return WithReadMask(ctx, mask), mask, nil
+}
+
+// pruneItems clears the fields that are not in the read mask, the key fields are always returned.
+func pruneItems[OITP proto.Message](items []OITP, mask *fieldmaskpb.FieldMask, keyFields []string) {
+	if mask == nil {
+		return
+	}
+
+	for _, item := range items {
+		scrudvalue.PruneMasked(item, mask, keyFields...)
+	}
+}
@@ -0,0 +1,227 @@
+package scrudruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/advdv/scrud/internal/config"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// RetentionPolicy configures how long archived rows of an entity are kept before they are purged.
+type RetentionPolicy struct {
+	// name of the entity, used for logging.
+	Entity string
+	// base table with the "archived_at" column.
+	Table string
+	// primary key columns of the table, defaults to "id".
+	KeyColumns []string
+	// archived rows older than this are purged, policies without a retention are ignored.
+	Retention time.Duration
+}
+
+// RetentionPoliciesFromConfig loads the configuration file of the buf plugin and returns a policy for every
+// entity with an archive retention, sorted by entity. The tables are named after the entities.
+func RetentionPoliciesFromConfig(filename string) ([]RetentionPolicy, error) {
+	cfg, err := config.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	var policies []RetentionPolicy
+	for _, name := range slices.Sorted(maps.Keys(cfg.Entities)) {
+		ent := cfg.Entities[name]
+		if ent.ArchiveRetention <= 0 {
+			continue
+		}
+
+		keyCols := ent.CompositeKeyFields()
+		if len(keyCols) < 1 {
+			field, _ := ent.Key()
+			keyCols = []string{field}
+		}
+
+		policies = append(policies, RetentionPolicy{
+			Entity:     name,
+			Table:      name,
+			KeyColumns: keyCols,
+			Retention:  ent.ArchiveRetention,
+		})
+	}
+
+	return policies, nil
+}
+
+// Retention periodically purges archived rows that are older than the retention of their entity. Rows are
+// deleted in bounded batches, each in their own transaction, so the job never holds locks for long. The database
+// is typically a *pgxpool.Pool.
+type Retention struct {
+	// database the tables are in.
+	DB Beginner
+	// policies for each entity.
+	Policies []RetentionPolicy
+	// logs for the job.
+	Logs *zap.Logger
+	// maximum number of rows that are deleted per transaction, defaults to 500.
+	BatchSize int
+	// how long to wait between sweeps, defaults to one hour.
+	Interval time.Duration
+	// only count the rows that would be purged, without deleting them.
+	DryRun bool
+	// Now returns the current time, defaults to time.Now.
+	Now func() time.Time
+	// OnSweep is called after each entity is swept with the number of rows that were (or would be) purged, for
+	// example to record metrics.
+	OnSweep func(policy RetentionPolicy, num int64, dryRun bool)
+	// OnPurge is called with the ids of every batch of purged rows, in the transaction that deletes them. It
+	// should remove any data about the rows that is kept elsewhere, e.g. with scrudchange.RedactPurged.
+	OnPurge func(ctx context.Context, tx pgx.Tx, policy RetentionPolicy, ids []string) error
+}
+
+// Run sweeps all policies until the context is cancelled.
+func (r Retention) Run(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	for {
+		if _, err := r.Sweep(ctx); err != nil {
+			r.logs().Error("failed to sweep archived rows", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Sweep purges the expired rows of all policies and returns how many rows were (or would be) purged per
+// entity. A failing entity doesn't stop the other entities from being swept.
+func (r Retention) Sweep(ctx context.Context) (map[string]int64, error) {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+
+	var sweepErr error
+	purged := make(map[string]int64, len(r.Policies))
+	for _, policy := range r.Policies {
+		if policy.Retention <= 0 {
+			continue
+		}
+
+		logs := r.logs().With(zap.String("entity", policy.Entity), zap.Bool("dry_run", r.DryRun))
+		num, err := r.sweepPolicy(ctx, policy, now().Add(-policy.Retention))
+		purged[policy.Entity] = num
+		if err != nil {
+			logs.Error("failed to purge expired archived rows", zap.Error(err), zap.Int64("num_purged", num))
+			sweepErr = errors.Join(sweepErr, fmt.Errorf("sweep '%s': %w", policy.Entity, err))
+			continue
+		}
+
+		logs.Info("purged expired archived rows", zap.Int64("num_purged", num),
+			zap.Duration("retention", policy.Retention))
+		if r.OnSweep != nil {
+			r.OnSweep(policy, num, r.DryRun)
+		}
+	}
+
+	return purged, sweepErr
+}
+
+// sweepPolicy deletes batches of rows archived before the cutoff until no batch is full anymore.
+func (r Retention) sweepPolicy(ctx context.Context, policy RetentionPolicy, cutoff time.Time) (int64, error) {
+	tbl := pgx.Identifier{policy.Table}.Sanitize()
+	if r.DryRun {
+		var num int64
+		tx, err := r.DB.Begin(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("begin: %w", err)
+		}
+
+		defer func() { _ = tx.Rollback(ctx) }()
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT count(*) FROM %s WHERE archived_at < $1`, tbl),
+			cutoff).Scan(&num); err != nil {
+			return 0, fmt.Errorf("count expired rows: %w", err)
+		}
+
+		return num, nil
+	}
+
+	var total int64
+	for {
+		num, err := r.deleteBatch(ctx, policy, cutoff)
+		total += num
+		if err != nil {
+			return total, err
+		}
+
+		if num < int64(r.batchSize()) {
+			return total, nil
+		}
+	}
+}
+
+func (r Retention) deleteBatch(ctx context.Context, policy RetentionPolicy, cutoff time.Time) (int64, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+
+	defer func() { _ = tx.Rollback(ctx) }() // no-op after commit
+
+	keyCols := keyColumnsOrID(policy.KeyColumns)
+	keys := make([]string, 0, len(keyCols))
+	for _, col := range keyCols {
+		keys = append(keys, pgx.Identifier{col}.Sanitize())
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`DELETE FROM %[1]s WHERE (%[2]s) IN (SELECT %[2]s FROM %[1]s `+
+		`WHERE archived_at < $1 ORDER BY archived_at LIMIT $2 FOR UPDATE SKIP LOCKED) RETURNING %[2]s`,
+		pgx.Identifier{policy.Table}.Sanitize(), strings.Join(keys, ", ")), cutoff, r.batchSize())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired rows: %w", err)
+	}
+
+	ids, err := collectKeys(rows, keyCols)
+	if err != nil {
+		return 0, fmt.Errorf("collect purged ids: %w", err)
+	}
+
+	if r.OnPurge != nil && len(ids) > 0 {
+		if err := r.OnPurge(ctx, tx, policy, ids); err != nil {
+			return 0, fmt.Errorf("on purge: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+
+	return int64(len(ids)), nil
+}
+
+func (r Retention) batchSize() int {
+	if r.BatchSize <= 0 {
+		return 500
+	}
+
+	return r.BatchSize
+}
+
+func (r Retention) logs() *zap.Logger {
+	if r.Logs == nil {
+		return zap.NewNop()
+	}
+
+	return r.Logs
+}
@@ -0,0 +1,43 @@
+package scrudruntime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPoliciesFromConfig(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "scrud.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(`entities:
+  project:
+    archive_retention: 720h
+  task:
+    primary_key_field: task_id
+    archive_retention: 24h
+  membership:
+    composite_key: [organization_id, user_id]
+    archive_retention: 1h
+  user: {}
+`), 0o600))
+
+	policies, err := scrudruntime.RetentionPoliciesFromConfig(filename)
+	require.NoError(t, err)
+	require.Equal(t, []scrudruntime.RetentionPolicy{
+		{
+			Entity: "membership", Table: "membership", KeyColumns: []string{"organization_id", "user_id"},
+			Retention: time.Hour,
+		},
+		{Entity: "project", Table: "project", KeyColumns: []string{"id"}, Retention: 720 * time.Hour},
+		{Entity: "task", Table: "task", KeyColumns: []string{"task_id"}, Retention: 24 * time.Hour},
+	}, policies)
+
+	require.NoError(t, os.WriteFile(filename, []byte("entities:\n  project:\n    archive_retention: -1h\n"), 0o600))
+	_, err = scrudruntime.RetentionPoliciesFromConfig(filename)
+	require.ErrorContains(t, err, "ArchiveRetention")
+}
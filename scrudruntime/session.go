@@ -0,0 +1,77 @@
+package scrudruntime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// SettingOrganizationIDs is the (transaction local) Postgres setting that holds the comma-separated
+	// organization ids the current request is allowed to access. Row-level security policies read it.
+	SettingOrganizationIDs = "scrud.organization_ids"
+	// SettingActorID is the (transaction local) Postgres setting that holds the id of the actor performing
+	// the current request.
+	SettingActorID = "scrud.actor_id"
+)
+
+// Session describes on behalf of whom a request is executed. It is carried on the context so the transaction
+// layer can expose it to the database.
+type Session struct {
+	// organizations the actor is allowed to access.
+	OrganizationIDs []string
+	// identifies the actor (user, service account) that performs the request.
+	ActorID string
+}
+
+type sessionCtxKey struct{}
+
+// WithSession returns a context that carries the session.
+func WithSession(ctx context.Context, sess Session) context.Context {
+	return context.WithValue(ctx, sessionCtxKey{}, sess)
+}
+
+// SessionFromContext returns the session carried by the context, if any.
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	sess, ok := ctx.Value(sessionCtxKey{}).(Session)
+	return sess, ok
+}
+
+// Beginner can start a transaction, it is implemented by *pgx.Conn and *pgxpool.Pool.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// BeginWithSession starts a transaction and sets the session of the context as transaction local settings.
+func BeginWithSession(ctx context.Context, db Beginner) (pgx.Tx, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+
+	if err := SetLocalSession(ctx, tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// SetLocalSession sets the session from the context as transaction local settings, the equivalent of
+// `SET LOCAL`. If the context carries no session the settings are set to empty values so that row-level
+// security policies will not match any rows.
+func SetLocalSession(ctx context.Context, tx pgx.Tx) error {
+	sess, _ := SessionFromContext(ctx)
+
+	// NOTE: "SET LOCAL" does not support parameters, set_config with is_local=true is the equivalent.
+	if _, err := tx.Exec(ctx, `SELECT set_config($1, $2, true), set_config($3, $4, true)`,
+		SettingOrganizationIDs, strings.Join(sess.OrganizationIDs, ","),
+		SettingActorID, sess.ActorID,
+	); err != nil {
+		return fmt.Errorf("set local session settings: %w", err)
+	}
+
+	return nil
+}
@@ -3,15 +3,26 @@ package scrudruntime
 import (
 	"context"
 	"errors"
-	"fmt"
 	"slices"
 	"strings"
 	"time"
 
 	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
 	"github.com/jackc/pgx/v5"
 )
 
+// NotFoundError reports the ids that could not be found, it is wrapped in the connect error of IsOneNotFound.
+type NotFoundError struct {
+	IDs []string
+}
+
+func (e *NotFoundError) Error() string {
+	return "could not find id(s): " + strings.Join(e.IDs, ",")
+}
+
+// IsOneNotFound returns a not found error if any of the expected ids is not in the actual ids. The missing ids
+// can be retrieved from it with errors.As and a *NotFoundError.
 func IsOneNotFound(actualIDs, expectedIDs []string) error {
 	if len(actualIDs) != len(expectedIDs) {
 		var notFoundIDs []string
@@ -23,13 +34,14 @@ func IsOneNotFound(actualIDs, expectedIDs []string) error {
 			notFoundIDs = append(notFoundIDs, id)
 		}
 
-		return connect.NewError(connect.CodeNotFound,
-			fmt.Errorf("could not find id(s): %s", strings.Join(notFoundIDs, ",")))
+		return connect.NewError(connect.CodeNotFound, &NotFoundError{IDs: notFoundIDs})
 	}
 
 	return nil
 }
 
+// RowsToItems maps rows to items in the order of ids, rows are identified by their string "id" column. Use
+// RowsToItemsFunc for tables with another (or a composite) primary key.
 func RowsToItems[
 	// slice of rows.
 	Ts ~[]T,
@@ -44,18 +56,40 @@ func RowsToItems[
 	tx pgx.Tx,
 	ids []string,
 	rows Ts,
-	considerArchived bool, // wether archived rows should be considered, else they'll cause a not_found error.
+	filter scrudv1.ArchiveFilter, // rows that don't pass the filter cause a not_found error.
+	mapf func(ctx context.Context, tx pgx.Tx, row T) (IT, error),
+) (items []IT, err error) {
+	return RowsToItemsFunc(ctx, tx, ids, rows, filter, T.GetID, mapf)
+}
+
+// RowsToItemsFunc is like RowsToItems, but the id of each row is determined by keyf. It is used for rows that
+// have a primary key other than a string "id" column, keyf then typically formats it with KeyString.
+func RowsToItemsFunc[
+	// slice of rows.
+	Ts ~[]T,
+	T interface {
+		GetArchivedAt() *time.Time
+	},
+	// item type
+	IT any,
+](
+	ctx context.Context,
+	tx pgx.Tx,
+	ids []string,
+	rows Ts,
+	filter scrudv1.ArchiveFilter, // rows that don't pass the filter cause a not_found error.
+	keyf func(row T) string,
 	mapf func(ctx context.Context, tx pgx.Tx, row T) (IT, error),
 ) (items []IT, err error) {
 	actualIDs := make([]string, 0, len(rows))
 	for _, row := range rows {
-		// if the row is archived, and we have a request that should not consider these to exist. We don't
+		// if the row doesn't pass the filter, we have a request that should not consider it to exist. We don't
 		// add them to the actual ids we found. That will trigger a not_found below.
-		if row.GetArchivedAt() != nil && !considerArchived {
+		if !filter.Includes(row.GetArchivedAt() != nil) {
 			continue
 		}
 
-		actualIDs = append(actualIDs, row.GetID())
+		actualIDs = append(actualIDs, keyf(row))
 	}
 
 	if err := IsOneNotFound(actualIDs, ids); err != nil {
@@ -67,7 +101,7 @@ func RowsToItems[
 	// non-linear complexity but we should be ok with the relatively small page sizes.
 	for _, id := range ids {
 		for _, row := range rows {
-			if row.GetID() == id {
+			if keyf(row) == id {
 				mapped, merr := mapf(ctx, tx, row)
 				if merr != nil {
 					err = errors.Join(err, merr)
@@ -0,0 +1,65 @@
+package scrudruntime_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestListPageSQLite(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	_, err = tx.ExecContext(t.Context(), `CREATE TABLE foo (id TEXT PRIMARY KEY, created_at DATETIME NOT NULL, `+
+		`organization_id TEXT NOT NULL, archived_at DATETIME)`)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(t.Context(), `CREATE VIEW foo_live AS SELECT * FROM foo WHERE archived_at IS NULL`)
+	require.NoError(t, err)
+
+	// two rows share their creation time, so the pages tie-break on the id.
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, row := range []struct {
+		id      string
+		created time.Time
+		org     string
+	}{
+		{"foo_1", created, "org_1"},
+		{"foo_2", created, "org_1"},
+		{"foo_3", created.Add(time.Second), "org_1"},
+		{"foo_4", created, "org_2"},
+	} {
+		_, err = tx.ExecContext(t.Context(), `INSERT INTO foo (id, created_at, organization_id) VALUES (?, ?, ?)`,
+			row.id, row.created, row.org)
+		require.NoError(t, err)
+	}
+
+	scope := scrudruntime.WithScope(scrudruntime.Scope{Column: "organization_id", Value: "org_1"})
+
+	var pages [][]string
+	var cursor []byte
+	for {
+		ids, next, _, err := scrudruntime.ListPage(t.Context(), scrudruntime.SQLTx(tx), scrudruntime.DialectSQLite,
+			pageInput{perPage: 2, cursor: cursor}, "foo", scope)
+		require.NoError(t, err)
+
+		pages = append(pages, ids)
+		if len(next) < 1 {
+			break
+		}
+
+		cursor = next
+	}
+
+	require.Equal(t, [][]string{{"foo_1", "foo_2"}, {"foo_3"}}, pages)
+}
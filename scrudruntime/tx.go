@@ -0,0 +1,175 @@
+package scrudruntime
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob"
+)
+
+// Tx is a database transaction that is independent of the driver, so helpers that use it run on pgx and on
+// database/sql drivers alike. Use PgxTx and SQLTx to adapt transactions of either. Only listing is driver
+// independent: ListPage implements the list function of the Exec helpers with it, which run with an executor
+// of any type as long as no options are used that write to the transaction. The other helpers (e.g. for
+// purging, versioning and cascading) and those options rely on Postgres, and take a pgx.Tx.
+type Tx interface {
+	// Exec executes the statement and returns the number of affected rows.
+	Exec(ctx context.Context, sql string, args ...any) (int64, error)
+	// Query executes the query and returns its rows.
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+}
+
+// Rows are the result of a query, they must be closed when done.
+type Rows interface {
+	Columns() []string
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close() error
+}
+
+// PgxTx adapts a pgx transaction to Tx.
+func PgxTx(tx pgx.Tx) Tx { return pgxTx{tx} }
+
+type pgxTx struct{ tx pgx.Tx }
+
+func (t pgxTx) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	tag, err := t.tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (t pgxTx) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	rows, err := t.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgxRows{rows}, nil
+}
+
+type pgxRows struct{ pgx.Rows }
+
+func (r pgxRows) Columns() []string {
+	fields := r.FieldDescriptions()
+	cols := make([]string, 0, len(fields))
+	for _, fd := range fields {
+		cols = append(cols, fd.Name)
+	}
+
+	return cols
+}
+
+func (r pgxRows) Close() error {
+	r.Rows.Close()
+	return r.Rows.Err()
+}
+
+// SQLTx adapts a database/sql transaction to Tx.
+func SQLTx(tx *sql.Tx) Tx { return sqlTx{tx} }
+
+type sqlTx struct{ tx *sql.Tx }
+
+func (t sqlTx) Exec(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := t.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (t sqlTx) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("get columns: %w", err)
+	}
+
+	return sqlRows{rows, cols}, nil
+}
+
+type sqlRows struct {
+	*sql.Rows
+	cols []string
+}
+
+func (r sqlRows) Columns() []string { return r.cols }
+
+// CollectMaps scans all rows into maps by column name, and closes them.
+func CollectMaps(rows Rows) (maps []map[string]any, err error) {
+	defer func() {
+		if cerr := rows.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("close rows: %w", cerr)
+		}
+	}()
+
+	cols := rows.Columns()
+	for rows.Next() {
+		vals := make([]any, len(cols))
+		dest := make([]any, len(cols))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		row := make(map[string]any, len(cols))
+		for i, col := range cols {
+			row[col] = vals[i]
+		}
+
+		maps = append(maps, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return maps, nil
+}
+
+// ListPage queries a page of rows in the dialect, and returns their ids and the cursors of the neighbouring
+// pages. It implements the list function of ListAndDescribePerBatch on any driver.
+func ListPage[I pageInput](
+	ctx context.Context,
+	tx Tx,
+	d Dialect,
+	inp I,
+	baseTableName string,
+	opts ...PaginateOption,
+) (ids []string, nextCursor, prevCursor []byte, err error) {
+	query, pagef, err := PaginateQuery(d, inp, baseTableName, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sql, args, err := bob.Build(ctx, query)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("build page query: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("query page: %w", err)
+	}
+
+	maps, err := CollectMaps(rows)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return pagef(maps)
+}
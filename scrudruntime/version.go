@@ -0,0 +1,193 @@
+package scrudruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/jackc/pgx/v5"
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/sm"
+	"github.com/stephenafamo/bob/dialect/psql/um"
+)
+
+// WithVersionCheck makes the Modify, Remove and Restore helpers check the versions that their input carries
+// before the mutation is executed. Modify items provide them with a "version" field, Remove and Restore inputs
+// with a "versions" field that is parallel to the ids. The versions of the rows in the base table are
+// incremented by the check, so the mutation itself should not do that again.
+func WithVersionCheck(baseTableName string) Option[Env] {
+	return WithVersionCheckExec[Env](baseTableName)
+}
+
+// WithVersionCheckExec is WithVersionCheck for helpers that run with an executor of type E.
+func WithVersionCheckExec[E Executor](baseTableName string) Option[E] {
+	return func(o *options[E]) {
+		o.versionTable = baseTableName
+		o.executor = asExecutor[E]
+	}
+}
+
+// CheckVersions increments the versions of the rows with the given ids (or keys), but only if they still have
+// the versions the client last saw. It returns the errors of ExecVersioned if any of them doesn't.
+func CheckVersions[K ItemKey](
+	ctx context.Context, tx pgx.Tx, baseTableName string, ids []K, versions []int64, keyCols ...string,
+) error {
+	if len(ids) < 1 {
+		return nil
+	}
+
+	keyCols = keyColumnsOrID(keyCols)
+	match, err := VersionedWhere(ids, versions, keyCols...)
+	if err != nil {
+		return err
+	}
+
+	returning := make([]any, 0, len(keyCols))
+	for _, col := range quoteColumns(keyCols...) {
+		returning = append(returning, col)
+	}
+
+	return ExecVersioned(ctx, tx, baseTableName, ids, psql.Update(
+		um.Table(psql.Quote(baseTableName)),
+		um.SetCol("version").To(psql.Raw("version + 1")),
+		um.Where(match),
+		um.Returning(returning...),
+	), keyCols...)
+}
+
+// checkVersions checks the versions if the helper is configured to do so, and the input carries them.
+func checkVersions[E any, K ItemKey](ctx context.Context, o options[E], exec E, ids []K, versions []int64) error {
+	if o.versionTable == "" || versions == nil {
+		return nil
+	}
+
+	return CheckVersions(ctx, o.tx(exec), o.versionTable, ids, versions, o.keys()...)
+}
+
+// VersionedUpdateMods returns the mods that make an update of a single row conditional on the version the
+// client last saw, and increments the version. The key columns default to "id". The query must be executed
+// with ExecVersioned.
+func VersionedUpdateMods(id string, version int64, keyCols ...string) ([]bob.Mod[*dialect.UpdateQuery], error) {
+	keyCols = keyColumnsOrID(keyCols)
+	match, err := keyIn(keyCols, []string{id})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	returning := make([]any, 0, len(keyCols))
+	for _, col := range quoteColumns(keyCols...) {
+		returning = append(returning, col)
+	}
+
+	return []bob.Mod[*dialect.UpdateQuery]{
+		um.Where(match),
+		um.Where(psql.Quote("version").EQ(psql.Arg(version))),
+		um.SetCol("version").To(psql.Arg(version + 1)),
+		um.Returning(returning...),
+	}, nil
+}
+
+// VersionedWhere returns a where expression that matches rows with the given ids (or keys), but only if they
+// still have the version the client last saw. It can be used to make batch updates (remove, restore)
+// conditional. The key columns default to "id".
+func VersionedWhere[K ItemKey](ids []K, versions []int64, keyCols ...string) (bob.Expression, error) {
+	if len(ids) != len(versions) {
+		return nil, connect.NewError(connect.CodeInvalidArgument,
+			fmt.Errorf("number of versions (%d) doesn't match number of ids (%d)", len(versions), len(ids)))
+	}
+
+	keyCols = keyColumnsOrID(keyCols)
+	keys, err := toKeys(ids, len(keyCols))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	pairs := make([]bob.Expression, 0, len(keys))
+	for idx, key := range keys {
+		pairs = append(pairs, psql.ArgGroup(append(slices.Clone(key), versions[idx])...))
+	}
+
+	return psql.Group(append(quoteColumns(keyCols...), psql.Quote("version"))...).In(pairs...), nil
+}
+
+// ExecVersioned executes a versioned statement that is expected to affect all rows identified by ids. The
+// query must return the key columns (by default "id") of affected rows. Rows that were not affected either
+// don't exist in the base table, which is reported as not found, or have been modified concurrently which is
+// reported as aborted.
+func ExecVersioned[K ItemKey](
+	ctx context.Context, tx pgx.Tx, baseTableName string, ids []K, query bob.Query, keyCols ...string,
+) error {
+	keyCols = keyColumnsOrID(keyCols)
+	sql, args, err := bob.Build(ctx, query)
+	if err != nil {
+		return fmt.Errorf("build versioned query: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("execute versioned query: %w", err)
+	}
+
+	affected, err := collectKeys(rows, keyCols)
+	if err != nil {
+		return fmt.Errorf("collect affected ids: %w", err)
+	}
+
+	strs, err := keyIDs(ids)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	var unaffected []string
+	var unaffectedKeys []K
+	for idx, id := range strs {
+		if !slices.Contains(affected, id) {
+			unaffected, unaffectedKeys = append(unaffected, id), append(unaffectedKeys, ids[idx])
+		}
+	}
+
+	if len(unaffected) < 1 {
+		return nil
+	}
+
+	match, err := keyIn(keyCols, unaffectedKeys)
+	if err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	cols := make([]any, 0, len(keyCols))
+	for _, col := range quoteColumns(keyCols...) {
+		cols = append(cols, col)
+	}
+
+	sql, args, err = psql.Select(sm.Columns(cols...), sm.From(psql.Quote(baseTableName)), sm.Where(match)).Build(ctx)
+	if err != nil {
+		return fmt.Errorf("build existing ids query: %w", err)
+	}
+
+	if rows, err = tx.Query(ctx, sql, args...); err != nil {
+		return fmt.Errorf("query existing ids: %w", err)
+	}
+
+	existing, err := collectKeys(rows, keyCols)
+	if err != nil {
+		return fmt.Errorf("collect existing ids: %w", err)
+	}
+
+	if err := IsOneNotFound(existing, unaffected); err != nil {
+		return err
+	}
+
+	return VersionMismatchError(unaffected...)
+}
+
+// VersionMismatchError returns the error for items that have been modified since the client last saw them.
+func VersionMismatchError(ids ...string) error {
+	return connect.NewError(connect.CodeAborted,
+		errors.New("item(s) modified concurrently, version mismatch for: "+strings.Join(ids, ",")))
+}
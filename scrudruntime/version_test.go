@@ -0,0 +1,41 @@
+package scrudruntime_test
+
+import (
+	"testing"
+
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/um"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedWhere(t *testing.T) {
+	t.Parallel()
+
+	match, err := scrudruntime.VersionedWhere([]string{"a/1", "b/2"}, []int64{3, 4}, "org_id", "seq")
+	require.NoError(t, err)
+
+	sql, args, err := bob.Build(t.Context(), psql.Update(um.Table("foo"), um.SetCol("x").ToArg(1), um.Where(match)))
+	require.NoError(t, err)
+	require.Contains(t, sql, `WHERE (("org_id", "seq", "version") IN (($2, $3, $4), ($5, $6, $7)))`)
+	require.Equal(t, []any{1, "a", "1", int64(3), "b", "2", int64(4)}, args)
+
+	_, err = scrudruntime.VersionedWhere([]string{"a"}, nil)
+	require.ErrorContains(t, err, "number of versions (0) doesn't match number of ids (1)")
+}
+
+func TestVersionedWhereKeys(t *testing.T) {
+	t.Parallel()
+
+	// the parts of keys are passed as they are, so int64 columns receive integers.
+	match, err := scrudruntime.VersionedWhere([]scrudruntime.Key{{"a", int64(1)}}, []int64{3}, "org_id", "seq")
+	require.NoError(t, err)
+
+	_, args, err := bob.Build(t.Context(), psql.Update(um.Table("foo"), um.SetCol("x").ToArg(1), um.Where(match)))
+	require.NoError(t, err)
+	require.Equal(t, []any{1, "a", int64(1), int64(3)}, args)
+
+	_, err = scrudruntime.VersionedWhere([]scrudruntime.Key{{"a"}}, []int64{3}, "org_id", "seq")
+	require.ErrorContains(t, err, "key must have 2 parts, got: 1")
+}
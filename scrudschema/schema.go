@@ -0,0 +1,174 @@
+// Package scrudschema generates the database DDL that our standard crud implementation expects.
+package scrudschema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/advdv/scrud/internal/config"
+	"github.com/advdv/scrud/scrudruntime"
+	"github.com/jackc/pgx/v5"
+)
+
+// Table describes the base table of an entity for which DDL is generated. The table itself is expected to
+// exist already.
+type Table struct {
+	// name of the base table, the views are named after it.
+	Name string
+	// wether the table has an organization_id column that scopes the rows.
+	OrganizationScoped bool
+	// column that holds the organization of scoped rows, defaults to "organization_id".
+	TenantColumn string
+	// sql type of the tenant column, e.g. "uuid". The organization ids setting is cast to an array of it, it is
+	// compared as text if empty.
+	TenantColumnType string
+	// wether row-level security policies should be emitted for organization-scoped tables.
+	RowLevelSecurity bool
+	// text columns that are indexed for full-text search, no search column is generated if empty.
+	SearchColumns []string
+	// text search configuration of the search column, defaults to "simple".
+	SearchLanguage string
+	// column that references the parent, if the table is the child in a cascading archive relationship.
+	ParentForeignKey string
+	// sql type of the parent foreign key, e.g. "uuid". The column that records the parent a row was archived
+	// with has the same type, defaults to "text".
+	ParentForeignKeyType string
+}
+
+// keyColumnTypes are the sql types of the configured primary key types.
+var keyColumnTypes = map[string]string{"string": "text", "uuid": "uuid", "int64": "bigint"}
+
+// TablesFromConfig loads the configuration file of the buf plugin and returns the table of every entity, by the
+// name of the entity. The tables are named after the entities, settings that are not part of the configuration
+// (such as row-level security and column types) are left for the caller to set.
+func TablesFromConfig(filename string) (map[string]Table, error) {
+	cfg, err := config.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	tables := make(map[string]Table, len(cfg.Entities))
+	for name, ent := range cfg.Entities {
+		tbl := Table{
+			Name:               name,
+			OrganizationScoped: len(ent.Scope) < 1 && !ent.NotOrganizationScoped,
+			TenantColumn:       ent.TenantField,
+			SearchColumns:      ent.SearchColumns,
+			SearchLanguage:     ent.SearchLanguage,
+		}
+
+		// the cascade origin can only reference a single parent, it has the type of the parent's key.
+		if parents := cfg.ParentsOf(name); len(parents) == 1 {
+			for parent, fk := range parents {
+				tbl.ParentForeignKey = fk
+				tbl.ParentForeignKeyType = keyColumnTypes[cfg.Entities[parent].PrimaryKeyType]
+			}
+		}
+
+		tables[name] = tbl
+	}
+
+	return tables, nil
+}
+
+// Statements returns all DDL statements for the table, in the order they should be executed.
+func (t Table) Statements() []string {
+	return slices.Concat(t.Cascade(), t.Search(), t.Views(), t.Policies())
+}
+
+// DDL returns all statements as a single migration script.
+func (t Table) DDL() string {
+	return strings.Join(t.Statements(), ";\n\n") + ";\n"
+}
+
+// Cascade returns the statements for the column that records with which parent a row was archived, and an
+// index on the foreign key that archives and restores cascade through.
+func (t Table) Cascade() []string {
+	if t.ParentForeignKey == "" {
+		return nil
+	}
+
+	typ := t.ParentForeignKeyType
+	if typ == "" {
+		typ = "text"
+	}
+
+	return []string{
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`,
+			ident(t.Name), ident(scrudruntime.CascadeOriginColumn), typ),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (%s)`,
+			ident(t.Name+"_"+t.ParentForeignKey+"_idx"), ident(t.Name), ident(t.ParentForeignKey)),
+	}
+}
+
+// Search returns the statements for the generated tsvector column, and its GIN index. It must be executed
+// before the views are created so that they include the column.
+func (t Table) Search() []string {
+	if len(t.SearchColumns) < 1 {
+		return nil
+	}
+
+	lang := t.SearchLanguage
+	if lang == "" {
+		lang = scrudruntime.DefaultSearchLanguage
+	}
+
+	docs := make([]string, 0, len(t.SearchColumns))
+	for _, col := range t.SearchColumns {
+		docs = append(docs, fmt.Sprintf(`coalesce(%s, '')`, ident(col)))
+	}
+
+	return []string{
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s tsvector GENERATED ALWAYS AS `+
+			`(to_tsvector('%s', %s)) STORED`, ident(t.Name), ident(scrudruntime.SearchVectorColumn),
+			strings.ReplaceAll(lang, "'", "''"), strings.Join(docs, ` || ' ' || `)),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (%s)`,
+			ident(t.Name+"_"+scrudruntime.SearchVectorColumn+"_idx"), ident(t.Name),
+			ident(scrudruntime.SearchVectorColumn)),
+	}
+}
+
+// Views returns the statements for the views on live and archived rows. The views are security invokers so
+// that any row-level security policies on the base table also apply to them.
+func (t Table) Views() []string {
+	return []string{
+		fmt.Sprintf(`CREATE OR REPLACE VIEW %s WITH (security_invoker = true) AS SELECT * FROM %s `+
+			`WHERE archived_at IS NULL`, ident(t.Name+"_live"), ident(t.Name)),
+		fmt.Sprintf(`CREATE OR REPLACE VIEW %s WITH (security_invoker = true) AS SELECT * FROM %s `+
+			`WHERE archived_at IS NOT NULL`, ident(t.Name+"_archived"), ident(t.Name)),
+	}
+}
+
+// Policies returns the row-level security statements. It only returns statements for organization-scoped
+// tables that have row-level security enabled.
+func (t Table) Policies() []string {
+	if !t.OrganizationScoped || !t.RowLevelSecurity {
+		return nil
+	}
+
+	tenantCol := "organization_id"
+	if t.TenantColumn != "" {
+		tenantCol = ident(t.TenantColumn)
+	}
+
+	var cast string
+	if t.TenantColumnType != "" {
+		cast = "::" + t.TenantColumnType + "[]"
+	}
+
+	check := fmt.Sprintf(`%s = ANY (string_to_array(current_setting('%s', true), ',')%s)`,
+		tenantCol, scrudruntime.SettingOrganizationIDs, cast)
+
+	return []string{
+		fmt.Sprintf(`ALTER TABLE %s ENABLE ROW LEVEL SECURITY`, ident(t.Name)),
+		fmt.Sprintf(`ALTER TABLE %s FORCE ROW LEVEL SECURITY`, ident(t.Name)),
+		fmt.Sprintf(`DROP POLICY IF EXISTS %s ON %s`, ident(t.Name+"_organization_isolation"), ident(t.Name)),
+		fmt.Sprintf(`CREATE POLICY %s ON %s USING (%s) WITH CHECK (%s)`,
+			ident(t.Name+"_organization_isolation"), ident(t.Name), check, check),
+	}
+}
+
+func ident(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
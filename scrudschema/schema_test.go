@@ -0,0 +1,103 @@
+package scrudschema_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/advdv/scrud/scrudschema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableDDL(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		table  scrudschema.Table
+		expNum int
+	}{
+		{"not scoped", scrudschema.Table{Name: "user", RowLevelSecurity: true}, 2},
+		{"scoped without rls", scrudschema.Table{Name: "project", OrganizationScoped: true}, 2},
+		{"scoped with rls", scrudschema.Table{Name: "project", OrganizationScoped: true, RowLevelSecurity: true}, 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stmts := tt.table.Statements()
+			require.Len(t, stmts, tt.expNum)
+			require.Contains(t, stmts[0], `"`+tt.table.Name+`_live"`)
+			require.Contains(t, stmts[1], `"`+tt.table.Name+`_archived"`)
+		})
+	}
+
+	ddl := scrudschema.Table{Name: "project", OrganizationScoped: true, RowLevelSecurity: true}.DDL()
+	require.Contains(t, ddl, `CREATE POLICY "project_organization_isolation" ON "project" USING `+
+		`(organization_id = ANY (string_to_array(current_setting('scrud.organization_ids', true), ',')))`)
+
+	ddl = scrudschema.Table{
+		Name: "project", OrganizationScoped: true, RowLevelSecurity: true, TenantColumnType: "uuid",
+	}.DDL()
+	require.Contains(t, ddl, `USING (organization_id = ANY `+
+		`(string_to_array(current_setting('scrud.organization_ids', true), ',')::uuid[]))`)
+}
+
+func TestTableSearchDDL(t *testing.T) {
+	t.Parallel()
+
+	stmts := scrudschema.Table{Name: "project", SearchColumns: []string{"title", "description"}}.Statements()
+	require.Len(t, stmts, 4)
+	require.Equal(t, `ALTER TABLE "project" ADD COLUMN IF NOT EXISTS "search_vector" tsvector GENERATED ALWAYS AS `+
+		`(to_tsvector('simple', coalesce("title", '') || ' ' || coalesce("description", ''))) STORED`, stmts[0])
+	require.Equal(t, `CREATE INDEX IF NOT EXISTS "project_search_vector_idx" ON "project" USING GIN ("search_vector")`,
+		stmts[1])
+	require.Contains(t, stmts[2], `"project_live"`)
+}
+
+func TestTableCascadeDDL(t *testing.T) {
+	t.Parallel()
+
+	stmts := scrudschema.Table{Name: "task", ParentForeignKey: "project_id"}.Statements()
+	require.Len(t, stmts, 4)
+	require.Equal(t, `ALTER TABLE "task" ADD COLUMN IF NOT EXISTS "archived_cascade_from" text`, stmts[0])
+	require.Equal(t, `CREATE INDEX IF NOT EXISTS "task_project_id_idx" ON "task" ("project_id")`, stmts[1])
+
+	stmts = scrudschema.Table{Name: "task", ParentForeignKey: "project_id", ParentForeignKeyType: "bigint"}.Cascade()
+	require.Equal(t, `ALTER TABLE "task" ADD COLUMN IF NOT EXISTS "archived_cascade_from" bigint`, stmts[0])
+}
+
+func TestTablesFromConfig(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "scrud.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(`entities:
+  project:
+    primary_key_type: uuid
+    search_columns: [title, description]
+    search_language: english
+    children:
+      - entity: task
+        foreign_key: project_id
+  task:
+    search_columns: [title]
+  user:
+    not_organization_scoped: true
+`), 0o600))
+
+	tables, err := scrudschema.TablesFromConfig(filename)
+	require.NoError(t, err)
+	require.Equal(t, map[string]scrudschema.Table{
+		"project": {
+			Name: "project", OrganizationScoped: true, TenantColumn: "organization_id",
+			SearchColumns: []string{"title", "description"}, SearchLanguage: "english",
+		},
+		"task": {
+			Name: "task", OrganizationScoped: true, TenantColumn: "organization_id",
+			SearchColumns: []string{"title"}, SearchLanguage: "simple",
+			ParentForeignKey: "project_id", ParentForeignKeyType: "uuid",
+		},
+		"user": {Name: "user", TenantColumn: "organization_id", SearchLanguage: "simple"},
+	}, tables)
+
+	require.Contains(t, tables["project"].Search()[0], `to_tsvector('english', coalesce("title", '')`)
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"connectrpc.com/connect"
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 )
@@ -77,7 +78,6 @@ func TestList[
 		SetSortBy(v string)
 		SetSortDesc(v bool)
 		SetCursor(v []byte)
-		SetShowArchived(v bool)
 	},
 	// output
 	O any,
@@ -125,7 +125,7 @@ func TestList[
 		inp.SetPerPage(perPage)
 		inp.SetSortBy(sortByColumn)
 		inp.SetSortDesc(sortDesc)
-		inp.SetShowArchived(showArchived)
+		setShowArchived(inp, showArchived)
 		if len(nextCursor) > 0 {
 			inp.SetCursor(nextCursor)
 		}
@@ -172,7 +172,7 @@ func TestList[
 	inp.SetPerPage(perPage)
 	inp.SetSortBy(sortByColumn)
 	inp.SetSortDesc(sortDesc)
-	inp.SetShowArchived(showArchived)
+	setShowArchived(inp, showArchived)
 	inp.SetCursor(prevCursor)
 	possiblySetOrganizationID(inp)
 	resp, err := list(ctx, connect.NewRequest(inp))
@@ -195,7 +195,7 @@ func TestList[
 	inp.SetPerPage(perPage)
 	inp.SetSortBy(sortByColumn)
 	inp.SetSortDesc(sortDesc)
-	inp.SetShowArchived(showArchived)
+	setShowArchived(inp, showArchived)
 	inp.SetCursor(outp.GetNextCursor())
 	possiblySetOrganizationID(inp)
 	resp, err = list(ctx, connect.NewRequest(inp))
@@ -225,7 +225,7 @@ func TestList[
 		inp.SetPerPage(perPage)
 		inp.SetSortBy(sortByColumn)
 		inp.SetSortDesc(sortDesc)
-		inp.SetShowArchived(showArchived)
+		setShowArchived(inp, showArchived)
 		if len(prevCursor) > 0 {
 			inp.SetCursor(prevCursor)
 		}
@@ -246,6 +246,68 @@ func TestList[
 		}
 	}
 
+	//
+	// Jump to the edges, if the output supports it
+	//
+
+	if edges, ok := any(outp).(interface {
+		GetFirstCursor() []byte
+		GetLastCursor() []byte
+	}); ok {
+		listPage := func(cursor []byte, fromEnd bool) OP {
+			var inp IP = new(I)
+			inp.SetPerPage(perPage)
+			inp.SetSortBy(sortByColumn)
+			inp.SetSortDesc(sortDesc)
+			setShowArchived(inp, showArchived)
+			if len(cursor) > 0 {
+				inp.SetCursor(cursor)
+			}
+
+			if fromEnd {
+				any(inp).(interface{ SetFromEnd(v bool) }).SetFromEnd(true) //nolint:forcetypeassert
+			}
+
+			possiblySetOrganizationID(inp)
+			resp, err := list(ctx, connect.NewRequest(inp))
+			require.NoError(tb, err)
+			return resp.Msg
+		}
+
+		// the first page is the same as the first page without a cursor.
+		pageLen := min(len(forwardItems), int(perPage))
+		first := listPage(edges.GetFirstCursor(), false)
+		require.Equal(tb, forwardItems[:pageLen], first.GetItems())
+		require.Empty(tb, first.GetPreviousCursor())
+
+		// the last page is a full page, with the last items. With fewer items than fit on a page it is the
+		// whole list.
+		last := listPage(edges.GetLastCursor(), false)
+		require.Equal(tb, forwardItems[len(forwardItems)-pageLen:], last.GetItems())
+		if len(forwardItems) <= int(perPage) {
+			require.Equal(tb, forwardItems, last.GetItems())
+		}
+
+		require.Empty(tb, last.GetNextCursor())
+		if _, ok := any(new(I)).(interface{ SetFromEnd(v bool) }); ok {
+			require.Equal(tb, last.GetItems(), listPage(nil, true).GetItems())
+		}
+
+		// walking back from the last page gives the same items as walking forward.
+		edgeItems, prevCursor := last.GetItems(), last.GetPreviousCursor()
+		for idx := 0; len(prevCursor) > 0; idx++ {
+			if idx == maxPagingIters-1 {
+				tb.Fatalf("too many paging iterations: %d", idx+1)
+			}
+
+			page := listPage(prevCursor, false)
+			edgeItems = append(page.GetItems(), edgeItems...) // NOTE: prepend!
+			prevCursor = page.GetPreviousCursor()
+		}
+
+		require.Equal(tb, forwardItems, edgeItems)
+	}
+
 	assert(backwardsItems)
 	assert(forwardItems)
 
@@ -412,6 +474,59 @@ func TestModify[
 	require.ErrorContains(tb, err, "not_found", "should error not_found")
 }
 
+// TestModifyStale checks optimistic concurrency control. It modifies the items with the versions that are
+// provided, which is expected to succeed. It then modifies the items again with the same, now stale, versions
+// which is expected to be rejected.
+func TestModifyStale[
+	// input item
+	IIT any,
+	IITP interface {
+		*IIT
+		proto.Message
+		SetVersion(v int64)
+	},
+	// input
+	I any,
+	IP interface {
+		*I
+		proto.Message
+		SetItems(v []IITP)
+	},
+	// output
+	O any,
+](
+	ctx context.Context,
+	tb testing.TB,
+	ids []string,
+	versions []int64,
+	gen func(idx int, id string) IITP,
+	modify func(
+		context.Context,
+		*connect.Request[I],
+	) (*connect.Response[O], error),
+) {
+	tb.Helper()
+	require.Len(tb, versions, len(ids))
+
+	items := make([]IITP, 0, len(ids))
+	for idx, id := range ids {
+		item := gen(idx, id)
+		item.SetVersion(versions[idx])
+		items = append(items, item)
+	}
+
+	var inp IP = new(I)
+	inp.SetItems(items)
+	_, err := modify(ctx, connect.NewRequest(inp))
+	require.NoError(tb, err)
+
+	// the versions have moved on, so the same write is now stale.
+	inp = new(I)
+	inp.SetItems(items)
+	_, err = modify(ctx, connect.NewRequest(inp))
+	require.ErrorContains(tb, err, "aborted", "should error aborted")
+}
+
 func TestDescribe[
 	// output item
 	OIT any,
@@ -557,3 +672,19 @@ func TestRestore[
 
 	return chosen
 }
+
+// setShowArchived sets the input to list either the live or the archived rows, inputs either have an
+// "archive_filter" or a "show_archived" field.
+func setShowArchived(inp any, showArchived bool) {
+	filter := scrudv1.ArchiveFilter_ARCHIVE_FILTER_LIVE
+	if showArchived {
+		filter = scrudv1.ArchiveFilter_ARCHIVE_FILTER_ARCHIVED
+	}
+
+	switch inp := inp.(type) {
+	case interface{ SetArchiveFilter(v scrudv1.ArchiveFilter) }:
+		inp.SetArchiveFilter(filter)
+	case interface{ SetShowArchived(v bool) }:
+		inp.SetShowArchived(showArchived)
+	}
+}
@@ -2,7 +2,6 @@ package scrudvalue
 
 import (
 	"database/sql"
-	"slices"
 
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
@@ -18,26 +17,29 @@ func FromNullable[T any](
 	return &sql.Null[T]{}
 }
 
+// FromMaskedNullable returns nil if the field is not covered by the mask. The field name may be a nested path
+// such as "address.city", it is covered when the mask includes it or any of its parents.
 func FromMaskedNullable[T any](
 	masked interface{ GetMask() *fieldmaskpb.FieldMask },
 	fieldName string,
 	hasf func() bool,
 	getf func() T,
 ) *sql.Null[T] {
-	if !slices.Contains(masked.GetMask().GetPaths(), fieldName) {
+	if !MaskCovers(masked.GetMask(), fieldName) {
 		return nil // don't set this value at all.
 	}
 
 	return FromNullable(hasf, getf)
 }
 
+// FromMasked returns nil if the field is not covered by the mask, see FromMaskedNullable.
 func FromMasked[T any](
 	masked interface{ GetMask() *fieldmaskpb.FieldMask },
 	fieldName string,
 	hasf func() bool,
 	getf func() T,
 ) *T {
-	if !slices.Contains(masked.GetMask().GetPaths(), fieldName) {
+	if !MaskCovers(masked.GetMask(), fieldName) {
 		return nil
 	}
 
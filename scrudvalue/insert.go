@@ -0,0 +1,150 @@
+package scrudvalue
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/im"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Insert configures how create items are inserted as rows.
+type Insert struct {
+	// table the rows are inserted into.
+	Table string
+	// how the fields of the items map onto columns.
+	Mapping Mapping
+	// NewID is called for every row to generate its id. If nil, the database default for the column is used.
+	NewID func() (string, error)
+	// OrganizationID is inserted into the tenant column, unless the item has such a field itself.
+	OrganizationID string
+	// column that holds the primary key, and is returned by the statement. Defaults to "id".
+	KeyColumn string
+	// column that holds the organization, defaults to "organization_id".
+	TenantColumn string
+	// Now returns the time used for the created_at and updated_at columns, defaults to time.Now.
+	Now func() time.Time
+}
+
+// InsertMods returns the mods for inserting all items as rows with a single statement. The statement returns
+// the ids of the inserted rows, in the order of the items. All fields of the items are inserted unless they are
+// configured to be skipped by the mapping.
+func InsertMods[T proto.Message](cfg Insert, items ...T) ([]bob.Mod[*dialect.InsertQuery], error) {
+	mods, _, err := insertMods(cfg, items...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(mods, im.Returning(cfg.keyColumn())), nil
+}
+
+// insertMods returns the mods for inserting the rows, and the columns that are inserted.
+func insertMods[T proto.Message](cfg Insert, items ...T) ([]bob.Mod[*dialect.InsertQuery], []string, error) {
+	if len(items) < 1 {
+		return nil, nil, errors.New("no items to insert")
+	}
+
+	desc := items[0].ProtoReflect().Descriptor()
+
+	var fields []protoreflect.FieldDescriptor
+	var cols []string
+	for i := range desc.Fields().Len() {
+		fd := desc.Fields().Get(i)
+		if cfg.Mapping.skips(fd) {
+			continue
+		}
+
+		fields = append(fields, fd)
+		cols = append(cols, cfg.Mapping.Column(fd))
+	}
+
+	// the extra columns are only added if the item doesn't provide them itself.
+	keyCol, tenantCol := cfg.keyColumn(), cfg.tenantColumn()
+	withID := cfg.NewID != nil && !slices.Contains(cols, keyCol)
+	withOrgID := cfg.OrganizationID != "" && !slices.Contains(cols, tenantCol)
+	withCreatedAt, withUpdatedAt := !slices.Contains(cols, "created_at"), !slices.Contains(cols, "updated_at")
+	for _, extra := range []struct {
+		col  string
+		with bool
+	}{{keyCol, withID}, {tenantCol, withOrgID}, {"created_at", withCreatedAt}, {"updated_at", withUpdatedAt}} {
+		if extra.with {
+			cols = append(cols, extra.col)
+		}
+	}
+
+	now := time.Now
+	if cfg.Now != nil {
+		now = cfg.Now
+	}
+
+	mods := []bob.Mod[*dialect.InsertQuery]{im.Into(cfg.Table, cols...)}
+	for idx, item := range items {
+		msg := item.ProtoReflect()
+		if msg.Descriptor().FullName() != desc.FullName() {
+			return nil, nil, fmt.Errorf("item %d is a %s, expected: %s", idx, msg.Descriptor().FullName(), desc.FullName())
+		}
+
+		vals := make(map[string]any, len(cols))
+		for i, fd := range fields {
+			val, err := cfg.Mapping.FieldToSQL(msg, fd)
+			if err != nil {
+				return nil, nil, fmt.Errorf("item %d: convert field '%s': %w", idx, fd.Name(), err)
+			}
+
+			vals[cols[i]] = val
+		}
+
+		if withID {
+			id, err := cfg.NewID()
+			if err != nil {
+				return nil, nil, fmt.Errorf("item %d: generate id: %w", idx, err)
+			}
+
+			vals[keyCol] = id
+		}
+
+		if withOrgID {
+			vals[tenantCol] = cfg.OrganizationID
+		}
+
+		ts := now()
+		if withCreatedAt {
+			vals["created_at"] = ts
+		}
+
+		if withUpdatedAt {
+			vals["updated_at"] = ts
+		}
+
+		row := make([]bob.Expression, 0, len(cols))
+		for _, col := range cols {
+			row = append(row, psql.Arg(vals[col]))
+		}
+
+		mods = append(mods, im.Values(row...))
+	}
+
+	return mods, cols, nil
+}
+
+func (cfg Insert) keyColumn() string {
+	if cfg.KeyColumn == "" {
+		return "id"
+	}
+
+	return cfg.KeyColumn
+}
+
+func (cfg Insert) tenantColumn() string {
+	if cfg.TenantColumn == "" {
+		return "organization_id"
+	}
+
+	return cfg.TenantColumn
+}
@@ -0,0 +1,71 @@
+package scrudvalue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/advdv/scrud/scrudvalue"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestInsertMods(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)
+	items := []*descriptorpb.EnumValueDescriptorProto{
+		{Name: proto.String("FOO"), Number: proto.Int32(1)},
+		{Name: proto.String("BAR")},
+	}
+
+	var num int
+	mods, err := scrudvalue.InsertMods(scrudvalue.Insert{
+		Table:          "enum_value",
+		Mapping:        scrudvalue.Mapping{Skip: []string{"options"}},
+		OrganizationID: "org_1",
+		Now:            func() time.Time { return now },
+		NewID: func() (string, error) {
+			num++
+			return "ev_" + string(rune('0'+num)), nil
+		},
+	}, items...)
+	require.NoError(t, err)
+
+	sql, args, err := psql.Insert(mods...).Build(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO enum_value("name", "number", "id", "organization_id", "created_at", "updated_at")`+
+		"\nVALUES ($1, $2, $3, $4, $5, $6), ($7, $8, $9, $10, $11, $12)\nRETURNING id\n", sql)
+	require.Equal(t, []any{
+		"FOO", int32(1), "ev_1", "org_1", now, now,
+		"BAR", nil, "ev_2", "org_1", now, now,
+	}, args)
+
+	_, err = scrudvalue.InsertMods[*descriptorpb.EnumValueDescriptorProto](scrudvalue.Insert{Table: "enum_value"})
+	require.ErrorContains(t, err, "no items")
+}
+
+func TestUpsertMods(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)
+	mods, err := scrudvalue.UpsertMods(scrudvalue.Insert{
+		Table:   "enum_value",
+		Mapping: scrudvalue.Mapping{Skip: []string{"options"}},
+		Now:     func() time.Time { return now },
+	}, "name", &fieldmaskpb.FieldMask{Paths: []string{"number"}},
+		&descriptorpb.EnumValueDescriptorProto{Name: proto.String("FOO"), Number: proto.Int32(1)})
+	require.NoError(t, err)
+
+	sql, _, err := psql.Insert(mods...).Build(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, sql, `ON CONFLICT ("name") DO UPDATE SET`+"\n"+
+		`"number" = EXCLUDED. "number",`+"\n"+`"updated_at" = EXCLUDED. "updated_at"`)
+	require.Contains(t, sql, `RETURNING id, (xmax = 0) AS created`)
+
+	_, err = scrudvalue.UpsertMods(scrudvalue.Insert{Table: "enum_value"}, "bogus", nil,
+		&descriptorpb.EnumValueDescriptorProto{Name: proto.String("FOO")})
+	require.ErrorContains(t, err, "key column 'bogus' is not inserted")
+}
@@ -0,0 +1,180 @@
+package scrudvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Mapping configures how proto fields are mapped onto database columns.
+type Mapping struct {
+	// Columns maps field names to column names. Fields that are not mapped use their field name.
+	Columns map[string]string
+	// Skip lists the fields that are never mapped onto a column.
+	Skip []string
+	// EnumsAsNumbers stores enums as their number, by default the enum value's name is stored.
+	EnumsAsNumbers bool
+	// MapKeyPaths allows update masks to address individual keys of map fields, it should match the
+	// WithMapKeyMaskPaths option of the helper that validated the mask.
+	MapKeyPaths bool
+}
+
+// Column returns the column name for the field.
+func (m Mapping) Column(fd protoreflect.FieldDescriptor) string {
+	if col, ok := m.Columns[string(fd.Name())]; ok {
+		return col
+	}
+
+	return string(fd.Name())
+}
+
+// skips returns whether the field is never mapped.
+func (m Mapping) skips(fd protoreflect.FieldDescriptor) bool {
+	for _, name := range m.Skip {
+		if name == string(fd.Name()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FieldToSQL converts the value of the field in msg into a value that can be passed as a query argument. Fields
+// that support presence, but are not set, are converted to nil (NULL).
+func (m Mapping) FieldToSQL(msg protoreflect.Message, fd protoreflect.FieldDescriptor) (any, error) {
+	switch {
+	case fd.IsMap():
+		return m.mapToSQL(msg.Get(fd).Map(), fd)
+	case fd.IsList():
+		return m.listToSQL(msg.Get(fd).List(), fd)
+	case fd.HasPresence() && !msg.Has(fd):
+		return nil, nil
+	default:
+		return m.singularToSQL(msg.Get(fd), fd)
+	}
+}
+
+func (m Mapping) singularToSQL(val protoreflect.Value, fd protoreflect.FieldDescriptor) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if m.EnumsAsNumbers {
+			return int32(val.Enum()), nil
+		}
+
+		if ev := fd.Enum().Values().ByNumber(val.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+
+		return nil, fmt.Errorf("unknown enum number %d for field '%s'", val.Enum(), fd.Name())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToSQL(val.Message())
+	case protoreflect.BoolKind, protoreflect.StringKind, protoreflect.BytesKind,
+		protoreflect.FloatKind, protoreflect.DoubleKind,
+		protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return val.Interface(), nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %s for field '%s'", fd.Kind(), fd.Name())
+	}
+}
+
+// isScalarMessage returns whether the message is a well-known type that converts to a scalar sql value.
+func isScalarMessage(desc protoreflect.MessageDescriptor) bool {
+	switch desc.FullName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration",
+		"google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return true
+	default:
+		return false
+	}
+}
+
+// messageToSQL converts well-known types into their natural sql value, wrappers are unwrapped and all other
+// messages are converted to json.
+func messageToSQL(msg protoreflect.Message) (any, error) {
+	switch msg.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		return msg.Interface().(*timestamppb.Timestamp).AsTime(), nil //nolint:forcetypeassert
+	case "google.protobuf.Duration":
+		return msg.Interface().(*durationpb.Duration).AsDuration(), nil //nolint:forcetypeassert
+	default:
+		if isScalarMessage(msg.Descriptor()) { // wrappers
+			return msg.Get(msg.Descriptor().Fields().ByName("value")).Interface(), nil
+		}
+
+		buf, err := protojson.Marshal(msg.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s to json: %w", msg.Descriptor().FullName(), err)
+		}
+
+		return json.RawMessage(buf), nil
+	}
+}
+
+// listToSQL converts repeated fields into a typed slice, repeated messages are converted into a json array.
+func (m Mapping) listToSQL(list protoreflect.List, fd protoreflect.FieldDescriptor) (any, error) {
+	if fd.Kind() == protoreflect.MessageKind && !isScalarMessage(fd.Message()) {
+		elems := make([]json.RawMessage, 0, list.Len())
+		for i := range list.Len() {
+			buf, err := protojson.Marshal(list.Get(i).Message().Interface())
+			if err != nil {
+				return nil, fmt.Errorf("marshal element %d of '%s': %w", i, fd.Name(), err)
+			}
+
+			elems = append(elems, buf)
+		}
+
+		return json.Marshal(elems)
+	}
+
+	var slice reflect.Value
+	for i := range list.Len() {
+		elem, err := m.singularToSQL(list.Get(i), fd)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slice.IsValid() {
+			slice = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(elem)), 0, list.Len())
+		}
+
+		slice = reflect.Append(slice, reflect.ValueOf(elem))
+	}
+
+	if !slice.IsValid() {
+		return []any{}, nil // empty array, element type doesn't matter
+	}
+
+	return slice.Interface(), nil
+}
+
+// mapToSQL converts map fields into a json object.
+func (m Mapping) mapToSQL(mp protoreflect.Map, fd protoreflect.FieldDescriptor) (any, error) {
+	obj := make(map[string]any, mp.Len())
+
+	var err error
+	mp.Range(func(key protoreflect.MapKey, val protoreflect.Value) bool {
+		var elem any
+		if elem, err = m.singularToSQL(val, fd.MapValue()); err != nil {
+			return false
+		}
+
+		obj[key.String()] = elem
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(obj)
+}
@@ -0,0 +1,140 @@
+package scrudvalue
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// NormalizeMask returns a copy of the mask with its paths sorted, and any path that is covered by another path
+// removed. For example: ["address.city", "address", "name"] becomes ["address", "name"].
+func NormalizeMask(mask *fieldmaskpb.FieldMask) *fieldmaskpb.FieldMask {
+	norm := &fieldmaskpb.FieldMask{Paths: slices.Clone(mask.GetPaths())}
+	norm.Normalize()
+	return norm
+}
+
+// MaskCovers returns whether the path is included in the mask: either because the mask contains the path
+// itself, or one of its parents. A mask with "address" covers "address.city", but a mask with "address.city"
+// does not cover "address" since only part of the address is to be updated.
+func MaskCovers(mask *fieldmaskpb.FieldMask, path string) bool {
+	for _, maskPath := range mask.GetPaths() {
+		if maskPath == path || strings.HasPrefix(path, maskPath+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaskTouches returns whether the mask includes the path or any path below it. A mask with "address.city"
+// touches "address".
+func MaskTouches(mask *fieldmaskpb.FieldMask, path string) bool {
+	if MaskCovers(mask, path) {
+		return true
+	}
+
+	for _, maskPath := range mask.GetPaths() {
+		if strings.HasPrefix(maskPath, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prunePaths removes duplicate paths, and paths that are covered by another path, while keeping the order.
+func prunePaths(paths []string) (pruned []string) {
+	for _, path := range paths {
+		if slices.Contains(pruned, path) {
+			continue
+		}
+
+		if slices.ContainsFunc(paths, func(other string) bool { return strings.HasPrefix(path, other+".") }) {
+			continue
+		}
+
+		pruned = append(pruned, path)
+	}
+
+	return pruned
+}
+
+// ValidateMask checks that every path of the mask refers to a field of the message. Paths may traverse singular
+// message fields but never into repeated fields. Paths into map fields (e.g. "labels.foo") are only valid when
+// allowMapKeys is true and the map has string keys.
+func ValidateMask(msg proto.Message, mask *fieldmaskpb.FieldMask, allowMapKeys bool) error {
+	for _, path := range mask.GetPaths() {
+		if _, _, err := resolvePath(msg.ProtoReflect().Descriptor(), path, allowMapKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolvePath resolves the fields along a path. If the path ends with a map key, it is returned separately.
+func resolvePath(desc protoreflect.MessageDescriptor, path string, allowMapKeys bool) (
+	fields []protoreflect.FieldDescriptor, mapKey string, err error,
+) {
+	segments := strings.Split(path, ".")
+	for idx, seg := range segments {
+		if desc == nil {
+			return nil, "", fmt.Errorf("mask path '%s' traverses into a non-message field", path)
+		}
+
+		fd := desc.Fields().ByName(protoreflect.Name(seg))
+		if fd == nil {
+			return nil, "", fmt.Errorf("mask path '%s' is not a field of %s", path, desc.FullName())
+		}
+
+		fields = append(fields, fd)
+		isLast := idx == len(segments)-1
+		switch {
+		case isLast:
+		case fd.IsMap():
+			if !allowMapKeys || fd.MapKey().Kind() != protoreflect.StringKind || idx != len(segments)-2 {
+				return nil, "", fmt.Errorf("mask path '%s' traverses into a map field", path)
+			}
+
+			return fields, segments[idx+1], nil
+		case fd.IsList():
+			return nil, "", fmt.Errorf("mask path '%s' traverses into a repeated field", path)
+		case fd.Kind() != protoreflect.MessageKind:
+			return nil, "", fmt.Errorf("mask path '%s' traverses into a non-message field", path)
+		default:
+			desc = fd.Message()
+		}
+	}
+
+	return fields, "", nil
+}
+
+// PruneMasked clears all fields of the message that are not included in the mask. Fields of nested messages
+// are pruned when the mask only includes some of their fields. Top-level fields named in keep are never cleared.
+func PruneMasked(item proto.Message, mask *fieldmaskpb.FieldMask, keep ...string) {
+	pruneMessage(item.ProtoReflect(), mask, "", keep)
+}
+
+func pruneMessage(msg protoreflect.Message, mask *fieldmaskpb.FieldMask, prefix string, keep []string) {
+	var fields []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+
+	for _, fd := range fields {
+		path := prefix + string(fd.Name())
+		switch {
+		case prefix == "" && slices.Contains(keep, path), MaskCovers(mask, path):
+		case MaskTouches(mask, path) && fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			pruneMessage(msg.Mutable(fd).Message(), mask, path+".", nil)
+		default:
+			msg.Clear(fd)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package scrudvalue_test
+
+import (
+	"testing"
+
+	"github.com/advdv/scrud/scrudvalue"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestPruneMasked(t *testing.T) {
+	t.Parallel()
+
+	item := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("foo"),
+		Number:   proto.Int32(1),
+		JsonName: proto.String("fooBar"),
+		Options:  &descriptorpb.FieldOptions{Deprecated: proto.Bool(true), Lazy: proto.Bool(true)},
+	}
+
+	scrudvalue.PruneMasked(item, &fieldmaskpb.FieldMask{Paths: []string{"json_name", "options.lazy"}}, "name")
+	require.True(t, proto.Equal(&descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("foo"),
+		JsonName: proto.String("fooBar"),
+		Options:  &descriptorpb.FieldOptions{Lazy: proto.Bool(true)},
+	}, item), item)
+}
+
+func TestMaskCovers(t *testing.T) {
+	t.Parallel()
+
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"address", "labels.foo"}}
+	require.True(t, scrudvalue.MaskCovers(mask, "address"))
+	require.True(t, scrudvalue.MaskCovers(mask, "address.city"))
+	require.False(t, scrudvalue.MaskCovers(mask, "addresses"))
+	require.False(t, scrudvalue.MaskCovers(mask, "labels"))
+	require.True(t, scrudvalue.MaskTouches(mask, "labels"))
+
+	require.Equal(t, []string{"address", "name"}, scrudvalue.NormalizeMask(&fieldmaskpb.FieldMask{
+		Paths: []string{"name", "address.city", "address"},
+	}).GetPaths())
+}
@@ -0,0 +1,345 @@
+package scrudvalue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrNull is returned when a NULL value is converted into a value that cannot be unset, e.g. an element of a
+// repeated field.
+var ErrNull = errors.New("cannot convert NULL")
+
+// CollectItems scans all rows into new items. Columns are matched with fields by name, as configured by the
+// mapping. It can be used as the implementation of the mapping function for RowsToItems-style code.
+func CollectItems[T any, TP interface {
+	*T
+	proto.Message
+}](rows pgx.Rows, mapping Mapping) ([]TP, error) {
+	maps, err := pgx.CollectRows(rows, pgx.RowToMap)
+	if err != nil {
+		return nil, fmt.Errorf("collect rows: %w", err)
+	}
+
+	items := make([]TP, 0, len(maps))
+	for idx, row := range maps {
+		var item TP = new(T)
+		if err := mapping.FromRow(row, item); err != nil {
+			return nil, fmt.Errorf("row %d: %w", idx, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// FromRow sets the fields of the message from the columns of the row. Columns that are NULL leave the field
+// unset, columns that are missing from the row are ignored.
+func (m Mapping) FromRow(row map[string]any, item proto.Message) error {
+	msg := item.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if m.skips(fd) {
+			continue
+		}
+
+		col, ok := row[m.Column(fd)]
+		if !ok || col == nil {
+			continue
+		}
+
+		if err := m.fieldFromSQL(msg, fd, col); err != nil {
+			return fmt.Errorf("field '%s' from column '%s': %w", fd.Name(), m.Column(fd), err)
+		}
+	}
+
+	return nil
+}
+
+func (m Mapping) fieldFromSQL(msg protoreflect.Message, fd protoreflect.FieldDescriptor, col any) error {
+	switch {
+	case fd.IsMap():
+		obj, err := asJSON[map[string]any](col)
+		if err != nil {
+			return err
+		}
+
+		mp := msg.Mutable(fd).Map()
+		for key, elem := range obj {
+			val, err := m.singularFromSQL(mp.NewValue(), fd.MapValue(), elem)
+			if err != nil {
+				return fmt.Errorf("map key '%s': %w", key, err)
+			}
+
+			mk, err := mapKeyFromJSON(fd.MapKey(), key)
+			if err != nil {
+				return err
+			}
+
+			mp.Set(mk, val)
+		}
+	case fd.IsList():
+		elems, err := asList(col)
+		if err != nil {
+			return err
+		}
+
+		list := msg.Mutable(fd).List()
+		for idx, elem := range elems {
+			val, err := m.singularFromSQL(list.NewElement(), fd, elem)
+			if err != nil {
+				return fmt.Errorf("element %d: %w", idx, err)
+			}
+
+			list.Append(val)
+		}
+	default:
+		var empty protoreflect.Value
+		if fd.Kind() == protoreflect.MessageKind {
+			empty = msg.NewField(fd)
+		}
+
+		val, err := m.singularFromSQL(empty, fd, col)
+		if err != nil {
+			return err
+		}
+
+		msg.Set(fd, val)
+	}
+
+	return nil
+}
+
+// mapKeyFromJSON parses the key of a json object as the kind of the map key.
+func mapKeyFromJSON(fd protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+	var val protoreflect.Value
+	var err error
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		val = protoreflect.ValueOfString(key)
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(key)
+		val = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var n int64
+		n, err = strconv.ParseInt(key, 10, 32)
+		val = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var n int64
+		n, err = strconv.ParseInt(key, 10, 64)
+		val = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var n uint64
+		n, err = strconv.ParseUint(key, 10, 32)
+		val = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var n uint64
+		n, err = strconv.ParseUint(key, 10, 64)
+		val = protoreflect.ValueOfUint64(n)
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("unsupported map key kind: %s", fd.Kind())
+	}
+
+	if err != nil {
+		return protoreflect.MapKey{}, fmt.Errorf("map key '%s' as %s: %w", key, fd.Kind(), err)
+	}
+
+	return val.MapKey(), nil
+}
+
+// singularFromSQL converts a single sql value. For message fields, empty must hold a new message to decode into.
+// NULL elements of arrays and json lists (or values of json objects) cannot be represented and are an error.
+func (m Mapping) singularFromSQL(empty protoreflect.Value, fd protoreflect.FieldDescriptor, col any) (
+	protoreflect.Value, error,
+) {
+	if col == nil {
+		return protoreflect.Value{}, ErrNull
+	}
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return enumFromSQL(fd.Enum(), col)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageFromSQL(empty.Message(), col)
+	case protoreflect.StringKind:
+		if uid, ok := col.([16]byte); ok { // uuid columns
+			return protoreflect.ValueOfString(pgtype.UUID{Bytes: uid, Valid: true}.String()), nil
+		}
+
+		return convertScalar[string](col, protoreflect.ValueOfString)
+	case protoreflect.BytesKind:
+		return convertScalar[[]byte](col, protoreflect.ValueOfBytes)
+	case protoreflect.BoolKind:
+		return convertScalar[bool](col, protoreflect.ValueOfBool)
+	case protoreflect.FloatKind:
+		return convertScalar[float32](col, protoreflect.ValueOfFloat32)
+	case protoreflect.DoubleKind:
+		return convertScalar[float64](col, protoreflect.ValueOfFloat64)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return convertScalar[int32](col, protoreflect.ValueOfInt32)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return convertScalar[int64](col, protoreflect.ValueOfInt64)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return convertScalar[uint32](col, protoreflect.ValueOfUint32)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return convertScalar[uint64](col, protoreflect.ValueOfUint64)
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported kind: %s", fd.Kind())
+	}
+}
+
+// convertScalar converts between compatible go types, e.g. from an int16 column into an int32 field.
+func convertScalar[T any](col any, valueOf func(T) protoreflect.Value) (protoreflect.Value, error) {
+	if v, ok := col.(T); ok {
+		return valueOf(v), nil
+	}
+
+	if col == nil {
+		return protoreflect.Value{}, ErrNull
+	}
+
+	src, dst := reflect.ValueOf(col), reflect.TypeFor[T]()
+	if src.Kind() == reflect.String && dst.Kind() != reflect.String {
+		return protoreflect.Value{}, fmt.Errorf("cannot convert %T to %s", col, dst)
+	}
+
+	if !src.CanConvert(dst) {
+		return protoreflect.Value{}, fmt.Errorf("cannot convert %T to %s", col, dst)
+	}
+
+	return valueOf(src.Convert(dst).Interface().(T)), nil //nolint:forcetypeassert
+}
+
+// enumFromSQL accepts the name of the enum value, or its number.
+func enumFromSQL(desc protoreflect.EnumDescriptor, col any) (protoreflect.Value, error) {
+	if name, ok := col.(string); ok {
+		ev := desc.Values().ByName(protoreflect.Name(name))
+		if ev == nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown %s value: %s", desc.FullName(), name)
+		}
+
+		return protoreflect.ValueOfEnum(ev.Number()), nil
+	}
+
+	num, err := convertScalar[int32](col, protoreflect.ValueOfInt32)
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+
+	return protoreflect.ValueOfEnum(protoreflect.EnumNumber(num.Int())), nil
+}
+
+// messageFromSQL decodes well-known types from their natural sql value, wrappers from their wrapped value and
+// all other messages from json.
+func messageFromSQL(msg protoreflect.Message, col any) (protoreflect.Value, error) {
+	switch desc := msg.Descriptor(); {
+	case desc.FullName() == "google.protobuf.Timestamp":
+		ts, ok := col.(time.Time)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("cannot convert %T to timestamp", col)
+		}
+
+		return protoreflect.ValueOfMessage(timestamppb.New(ts).ProtoReflect()), nil
+	case desc.FullName() == "google.protobuf.Duration":
+		var dur time.Duration
+		switch val := col.(type) {
+		case time.Duration:
+			dur = val
+		case pgtype.Interval:
+			const day = time.Hour * 24
+			dur = time.Duration(val.Microseconds)*time.Microsecond + time.Duration(val.Days)*day +
+				time.Duration(val.Months)*30*day
+		default:
+			return protoreflect.Value{}, fmt.Errorf("cannot convert %T to duration", col)
+		}
+
+		return protoreflect.ValueOfMessage(durationpb.New(dur).ProtoReflect()), nil
+	case isScalarMessage(desc): // wrappers
+		fd := desc.Fields().ByName("value")
+		val, err := (Mapping{}).singularFromSQL(protoreflect.Value{}, fd, col)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+
+		msg.Set(fd, val)
+		return protoreflect.ValueOfMessage(msg), nil
+	default:
+		buf, err := asJSONBytes(col)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+
+		if err := protojson.Unmarshal(buf, msg.Interface()); err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unmarshal %s from json: %w", desc.FullName(), err)
+		}
+
+		return protoreflect.ValueOfMessage(msg), nil
+	}
+}
+
+// asList returns the elements of an array column, or of a json array.
+func asList(col any) ([]any, error) {
+	switch val := col.(type) {
+	case []any:
+		return val, nil
+	case []byte, string, json.RawMessage:
+		return asJSON[[]any](val)
+	default:
+		rv := reflect.ValueOf(col)
+		if rv.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("cannot convert %T to a list", col)
+		}
+
+		elems := make([]any, rv.Len())
+		for i := range rv.Len() {
+			elems[i] = rv.Index(i).Interface()
+		}
+
+		return elems, nil
+	}
+}
+
+// asJSONBytes returns the json encoding of a (json) column. Decoded json is encoded again.
+func asJSONBytes(col any) ([]byte, error) {
+	switch val := col.(type) {
+	case []byte:
+		return val, nil
+	case json.RawMessage:
+		return val, nil
+	case string:
+		return []byte(val), nil
+	default:
+		return json.Marshal(val)
+	}
+}
+
+func asJSON[T any](col any) (v T, err error) {
+	if v, ok := col.(T); ok {
+		return v, nil
+	}
+
+	buf, err := asJSONBytes(col)
+	if err != nil {
+		return v, err
+	}
+
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return v, fmt.Errorf("unmarshal json: %w", err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,117 @@
+package scrudvalue_test
+
+import (
+	"testing"
+	"time"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudvalue"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFromRow(t *testing.T) {
+	t.Parallel()
+
+	var item descriptorpb.FieldDescriptorProto
+	require.NoError(t, scrudvalue.Mapping{Columns: map[string]string{"name": "title"}}.FromRow(map[string]any{
+		"title":     "foo",
+		"number":    int16(3),
+		"label":     "LABEL_REPEATED",
+		"type":      int64(9),
+		"json_name": nil,
+		"options":   map[string]any{"deprecated": true},
+		"other":     "ignored",
+	}, &item))
+
+	require.True(t, proto.Equal(&descriptorpb.FieldDescriptorProto{
+		Name:    proto.String("foo"),
+		Number:  proto.Int32(3),
+		Label:   descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Options: &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+	}, &item), "got: %v", &item)
+
+	require.ErrorContains(t, scrudvalue.Mapping{}.FromRow(map[string]any{"label": "BOGUS"}, &item),
+		"unknown google.protobuf.FieldDescriptorProto.Label value: BOGUS")
+}
+
+func TestFromRowWellKnownAndRepeated(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)
+
+	var crs scrudv1.Cursor
+	require.NoError(t, scrudvalue.Mapping{}.FromRow(map[string]any{"order_timestamp": when}, &crs))
+	require.Equal(t, when, crs.GetOrderTimestamp().AsTime())
+
+	require.NoError(t, scrudvalue.Mapping{}.FromRow(map[string]any{
+		"order_duration": pgtype.Interval{Microseconds: 1_000_000, Days: 1, Valid: true},
+	}, &crs))
+	require.Equal(t, 24*time.Hour+time.Second, crs.GetOrderDuration().AsDuration())
+
+	var enm descriptorpb.EnumDescriptorProto
+	require.NoError(t, scrudvalue.Mapping{}.FromRow(map[string]any{
+		"reserved_name": []any{"a", "b"},
+		"value":         []any{map[string]any{"name": "FOO", "number": float64(1)}},
+	}, &enm))
+	require.Equal(t, []string{"a", "b"}, enm.GetReservedName())
+	require.Equal(t, "FOO", enm.GetValue()[0].GetName())
+	require.Equal(t, int32(1), enm.GetValue()[0].GetNumber())
+
+	var val structpb.Value // jsonb into a struct
+	require.NoError(t, scrudvalue.Mapping{}.FromRow(map[string]any{
+		"struct_value": map[string]any{"x": "y"},
+	}, &val))
+	require.Equal(t, "y", val.GetStructValue().GetFields()["x"].GetStringValue())
+}
+
+func TestFromRowNullElementsAndMapKeys(t *testing.T) {
+	t.Parallel()
+
+	var enm descriptorpb.EnumDescriptorProto
+	require.ErrorIs(t, scrudvalue.Mapping{}.FromRow(map[string]any{"reserved_name": []any{"a", nil}}, &enm),
+		scrudvalue.ErrNull)
+
+	fdesc, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name: proto.String("maps.proto"), Package: proto.String("maps"), Syntax: proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name: proto.String("counts"), JsonName: proto.String("counts"), Number: proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".maps.Item.CountsEntry"),
+			}},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("CountsEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name: proto.String("key"), JsonName: proto.String("key"), Number: proto.Int32(1),
+					Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:  descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+				}, {
+					Name: proto.String("value"), JsonName: proto.String("value"), Number: proto.Int32(2),
+					Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:  descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				}},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+
+	desc := fdesc.Messages().ByName("Item")
+	item := dynamicpb.NewMessage(desc)
+	require.NoError(t, scrudvalue.Mapping{}.FromRow(map[string]any{"counts": `{"42": "foo"}`}, item))
+	require.Equal(t, "foo", item.Get(desc.Fields().ByName("counts")).Map().
+		Get(protoreflect.ValueOfInt64(42).MapKey()).String())
+
+	require.ErrorContains(t, scrudvalue.Mapping{}.FromRow(map[string]any{"counts": `{"x": "foo"}`},
+		dynamicpb.NewMessage(desc)), "map key 'x' as int64")
+}
@@ -0,0 +1,221 @@
+package scrudvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/um"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// SetColsFromMasked returns an update "SET" mod for every field of the item that is included in the mask. It
+// replaces hand-written FromMasked calls, for example:
+//
+//	sets, err := scrudvalue.SetColsFromMasked(item, item.GetMask(), scrudvalue.Mapping{})
+//	psql.Update(append(sets, um.Table("project"), um.Where(...))...)
+//
+// Fields that support presence but are not set in the item are cleared by setting the column to NULL. Nested
+// paths (e.g. "address.city") partially update the JSONB column of the top-level field with jsonb_set, and
+// paths to keys of map fields (e.g. "labels.foo") set or remove just that key if the mapping allows them.
+func SetColsFromMasked(
+	item proto.Message, mask *fieldmaskpb.FieldMask, mapping Mapping,
+) ([]bob.Mod[*dialect.UpdateQuery], error) {
+	msg := item.ProtoReflect()
+	var cols []string
+	nested := map[string]*jsonbUpdate{}
+	paths := prunePaths(mask.GetPaths())
+	mods := make([]bob.Mod[*dialect.UpdateQuery], 0, len(paths))
+	for _, path := range paths {
+		fields, mapKey, err := resolvePath(msg.Descriptor(), path, mapping.MapKeyPaths)
+		if err != nil {
+			return nil, err
+		}
+
+		if mapping.skips(fields[0]) {
+			continue
+		}
+
+		col := mapping.Column(fields[0])
+		if len(fields) == 1 && mapKey == "" {
+			val, err := mapping.FieldToSQL(msg, fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("convert field '%s': %w", path, err)
+			}
+
+			mods = append(mods, um.SetCol(col).ToArg(val))
+			continue
+		}
+
+		if fields[0].Kind() != protoreflect.MessageKind || isScalarMessage(fields[0].Message()) {
+			return nil, fmt.Errorf("mask path '%s' is nested, but '%s' is not stored as json", path, fields[0].Name())
+		}
+
+		upd, ok := nested[col]
+		if !ok {
+			upd = &jsonbUpdate{col: col}
+			nested[col], cols = upd, append(cols, col)
+		}
+
+		if err := upd.add(mapping, msg, fields, mapKey); err != nil {
+			return nil, fmt.Errorf("convert field '%s': %w", path, err)
+		}
+	}
+
+	for _, col := range cols {
+		mods = append(mods, um.SetCol(col).To(nested[col].expression()))
+	}
+
+	return mods, nil
+}
+
+// jsonbUpdate collects the partial updates of a single JSONB column.
+type jsonbUpdate struct {
+	col     string
+	parents [][]string
+	leafs   []jsonbLeaf
+}
+
+// jsonbLeaf sets (or removes, if value is nil) the value at the json path.
+type jsonbLeaf struct {
+	path  []string
+	value json.RawMessage
+}
+
+func (u *jsonbUpdate) add(
+	mapping Mapping, msg protoreflect.Message, fields []protoreflect.FieldDescriptor, mapKey string,
+) error {
+	// walk to the message that holds the last field, and determine the json path on the way.
+	var path []string
+	parent := msg
+	for i, fd := range fields[:len(fields)-1] {
+		parent = parent.Get(fd).Message()
+		if i > 0 {
+			path = append(path, fd.JSONName())
+			u.addParent(path)
+		}
+	}
+
+	leaf := fields[len(fields)-1]
+	if len(fields) > 1 {
+		path = append(path, leaf.JSONName())
+	}
+
+	if mapKey != "" {
+		if len(fields) > 1 {
+			u.addParent(path)
+		}
+
+		path = append(path, mapKey)
+	}
+
+	value, err := leafJSON(mapping, parent, leaf, mapKey, len(fields) == 1)
+	if err != nil {
+		return err
+	}
+
+	u.leafs = append(u.leafs, jsonbLeaf{path: path, value: value})
+	return nil
+}
+
+// addParent records that the object at path must exist before leafs below it can be set.
+func (u *jsonbUpdate) addParent(path []string) {
+	for _, existing := range u.parents {
+		if slices.Equal(existing, path) {
+			return
+		}
+	}
+
+	u.parents = append(u.parents, slices.Clone(path))
+}
+
+// expression builds the new value of the column. Parent objects are created first, since jsonb_set only creates
+// the last key of its path.
+func (u *jsonbUpdate) expression() bob.Expression {
+	col := psql.Quote(u.col)
+	expr := psql.Raw("COALESCE(NULLIF(?, 'null'::jsonb), '{}'::jsonb)", col)
+	for _, path := range u.parents {
+		expr = psql.Raw("jsonb_set(?, ?::text[], COALESCE(NULLIF(? #> ?::text[], 'null'::jsonb), '{}'::jsonb))",
+			expr, path, col, path)
+	}
+
+	for _, leaf := range u.leafs {
+		if leaf.value == nil {
+			expr = psql.Raw("(? #- ?::text[])", expr, leaf.path)
+			continue
+		}
+
+		expr = psql.Raw("jsonb_set(?, ?::text[], ?::jsonb)", expr, leaf.path, string(leaf.value))
+	}
+
+	return expr
+}
+
+// leafJSON encodes the value of the field as it appears in the json of its parent. The top-level map columns use
+// the encoding of the mapping, everything below a message uses protojson. Returns nil if the map key is absent.
+func leafJSON(
+	mapping Mapping, parent protoreflect.Message, fd protoreflect.FieldDescriptor, mapKey string, topLevel bool,
+) (json.RawMessage, error) {
+	if mapKey != "" {
+		val := parent.Get(fd).Map().Get(protoreflect.ValueOfString(mapKey).MapKey())
+		if !val.IsValid() {
+			return nil, nil
+		}
+
+		if topLevel {
+			elem, err := mapping.singularToSQL(val, fd.MapValue())
+			if err != nil {
+				return nil, err
+			}
+
+			return json.Marshal(elem)
+		}
+
+		tmp := parent.New()
+		tmp.Mutable(fd).Map().Set(protoreflect.ValueOfString(mapKey).MapKey(), val)
+		obj, err := protoJSONObject(tmp)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := map[string]json.RawMessage{}
+		if err := json.Unmarshal(obj[fd.JSONName()], &entries); err != nil {
+			return nil, fmt.Errorf("unmarshal map json: %w", err)
+		}
+
+		return entries[mapKey], nil
+	}
+
+	tmp := parent.New()
+	if parent.Has(fd) {
+		tmp.Set(fd, parent.Get(fd))
+	}
+
+	obj, err := protoJSONObject(tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj[fd.JSONName()], nil
+}
+
+// protoJSONObject returns the protojson encoding of the message, including its unpopulated fields, per field.
+func protoJSONObject(msg protoreflect.Message) (map[string]json.RawMessage, error) {
+	buf, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(msg.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s to json: %w", msg.Descriptor().FullName(), err)
+	}
+
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(buf, &obj); err != nil {
+		return nil, fmt.Errorf("unmarshal json: %w", err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,132 @@
+package scrudvalue_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	scrudv1 "github.com/advdv/scrud/scrud/v1"
+	"github.com/advdv/scrud/scrudvalue"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/um"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSetColsFromMasked(t *testing.T) {
+	t.Parallel()
+
+	item := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("foo"),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Options:  &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+		JsonName: nil, // not set, so it will be cleared.
+	}
+
+	sets, err := scrudvalue.SetColsFromMasked(item, &fieldmaskpb.FieldMask{
+		Paths: []string{"name", "label", "options", "json_name", "number"},
+	}, scrudvalue.Mapping{
+		Columns: map[string]string{"name": "title"},
+		Skip:    []string{"number"},
+	})
+	require.NoError(t, err)
+	require.Len(t, sets, 4)
+
+	sql, args, err := psql.Update(append(sets, um.Table("field"))...).Build(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, `UPDATE field SET
+"title" = $1,
+"label" = $2,
+"options" = $3,
+"json_name" = $4`, sql)
+	require.Equal(t, "foo", args[0])
+	require.Equal(t, "LABEL_REPEATED", args[1])
+	require.JSONEq(t, `{"deprecated":true}`, string(args[2].(json.RawMessage)))
+	require.Nil(t, args[3])
+
+	_, err = scrudvalue.SetColsFromMasked(item, &fieldmaskpb.FieldMask{Paths: []string{"bogus"}}, scrudvalue.Mapping{})
+	require.ErrorContains(t, err, "is not a field of")
+}
+
+func TestSetColsFromMaskedWellKnown(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)
+	item := scrudv1.Cursor_builder{OrderTimestamp: timestamppb.New(when)}.Build()
+
+	sets, err := scrudvalue.SetColsFromMasked(item, &fieldmaskpb.FieldMask{
+		Paths: []string{"order_timestamp", "order_duration"},
+	}, scrudvalue.Mapping{})
+	require.NoError(t, err)
+
+	_, args, err := psql.Update(append(sets, um.Table("cursor"))...).Build(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []any{when, nil}, args)
+}
+
+func TestSetColsFromMaskedNested(t *testing.T) {
+	t.Parallel()
+
+	item := &descriptorpb.FieldDescriptorProto{
+		Name: proto.String("foo"),
+		Options: &descriptorpb.FieldOptions{
+			Deprecated: proto.Bool(true),
+			Features:   &descriptorpb.FeatureSet{FieldPresence: descriptorpb.FeatureSet_EXPLICIT.Enum()},
+		},
+	}
+
+	sets, err := scrudvalue.SetColsFromMasked(item, &fieldmaskpb.FieldMask{
+		Paths: []string{"label.bogus"},
+	}, scrudvalue.Mapping{})
+	require.ErrorContains(t, err, "traverses into a non-message field")
+	require.Nil(t, sets)
+
+	sets, err = scrudvalue.SetColsFromMasked(item, &fieldmaskpb.FieldMask{
+		Paths: []string{"options.deprecated", "name", "options.features.field_presence"},
+	}, scrudvalue.Mapping{})
+	require.NoError(t, err)
+
+	sql, args, err := psql.Update(append(sets, um.Table("field"))...).Build(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, `UPDATE field SET
+"name" = $1,
+"options" = jsonb_set(jsonb_set(jsonb_set(COALESCE(NULLIF("options", 'null'::jsonb), '{}'::jsonb), `+
+		`$2::text[], COALESCE(NULLIF("options" #> $3::text[], 'null'::jsonb), '{}'::jsonb)), `+
+		`$4::text[], $5::jsonb), $6::text[], $7::jsonb)`, sql)
+	require.Equal(t, []any{
+		"foo",
+		[]string{"features"}, []string{"features"},
+		[]string{"deprecated"}, "true",
+		[]string{"features", "fieldPresence"}, `"EXPLICIT"`,
+	}, args)
+
+	_, err = scrudvalue.SetColsFromMasked(item, &fieldmaskpb.FieldMask{
+		Paths: []string{"options.uninterpreted_option.name"},
+	}, scrudvalue.Mapping{})
+	require.ErrorContains(t, err, "traverses into a repeated field")
+}
+
+func TestSetColsFromMaskedMapKey(t *testing.T) {
+	t.Parallel()
+
+	item, err := structpb.NewStruct(map[string]any{"foo": "bar"})
+	require.NoError(t, err)
+
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"fields.foo", "fields.dar"}}
+	_, err = scrudvalue.SetColsFromMasked(item, mask, scrudvalue.Mapping{})
+	require.ErrorContains(t, err, "traverses into a map field")
+
+	sets, err := scrudvalue.SetColsFromMasked(item, mask, scrudvalue.Mapping{MapKeyPaths: true})
+	require.NoError(t, err)
+
+	sql, args, err := psql.Update(append(sets, um.Table("data"))...).Build(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, `UPDATE data SET
+"fields" = (jsonb_set(COALESCE(NULLIF("fields", 'null'::jsonb), '{}'::jsonb), $1::text[], $2::jsonb) #- $3::text[])`,
+		sql)
+	require.Equal(t, []any{[]string{"foo"}, `"bar"`, []string{"dar"}}, args)
+}
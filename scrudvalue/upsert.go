@@ -0,0 +1,63 @@
+package scrudvalue
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/stephenafamo/bob"
+	"github.com/stephenafamo/bob/dialect/psql"
+	"github.com/stephenafamo/bob/dialect/psql/dialect"
+	"github.com/stephenafamo/bob/dialect/psql/im"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// UpsertMods returns the mods for inserting all items, or updating the rows that already exist with the same
+// value in the key column. The key column must have a unique constraint. If the mask has paths only the masked
+// columns are updated, otherwise all inserted columns are. Nested paths update their whole top-level column.
+// Since a statement has one mask, items with different masks must be upserted with separate statements.
+//
+// The statement returns the id of each row, and whether it was created (true) or updated (false).
+func UpsertMods[T proto.Message](
+	cfg Insert, key string, mask *fieldmaskpb.FieldMask, items ...T,
+) ([]bob.Mod[*dialect.InsertQuery], error) {
+	mods, cols, err := insertMods(cfg, items...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(cols, key) {
+		return nil, fmt.Errorf("key column '%s' is not inserted", key)
+	}
+
+	var masked []string
+	for _, path := range mask.GetPaths() {
+		fields, _, err := resolvePath(items[0].ProtoReflect().Descriptor(), path, true)
+		if err != nil {
+			return nil, err
+		}
+
+		masked = append(masked, cfg.Mapping.Column(fields[0]))
+	}
+
+	updateCols := slices.DeleteFunc(slices.Clone(cols), func(col string) bool {
+		switch {
+		case col == key, col == cfg.keyColumn(), col == cfg.tenantColumn(), col == "created_at":
+			return true // the identity of existing rows is never updated.
+		case col == "updated_at", len(masked) < 1:
+			return false
+		default:
+			return !slices.Contains(masked, col)
+		}
+	})
+
+	// a no-op update still locks and returns the existing row, "DO NOTHING" would not return it.
+	if len(updateCols) < 1 {
+		updateCols = []string{key}
+	}
+
+	return append(mods,
+		im.OnConflict(psql.Quote(key)).DoUpdate(im.SetExcluded(updateCols...)),
+		im.Returning(cfg.keyColumn(), psql.Raw("(xmax = 0) AS created")),
+	), nil
+}